@@ -3,7 +3,8 @@ module fifo_memory(
     input wire wr_en,
     input wire [15:0] data_in,
     input wire rd_en,
-    output reg [15:0] data_out
+    output reg [15:0] data_out,
+    output reg [4:0] level      // Число слов в FIFO (0..16)
 );
     reg [15:0] fifo [0:15];
     reg [3:0] write_ptr;
@@ -29,4 +30,16 @@ module fifo_memory(
             read_ptr <= read_ptr + 1;
         end
     end
+
+    // Уровень считает записанные и прочитанные слова и не выходит за 0..16:
+    // чтение пустого FIFO отмечает echo_underflow в fpga.v
+    wire wrote = wr_en && (count < 1000);
+
+    always @(posedge clk) begin
+        case ({wrote, rd_en})
+            2'b10: if (level != 5'd16) level <= level + 1;
+            2'b01: if (level != 5'd0) level <= level - 1;
+            default: level <= level;
+        endcase
+    end
 endmodule
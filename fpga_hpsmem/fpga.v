@@ -18,12 +18,41 @@ wire write_enable;
 wire read_enable;
 wire [15:0] echo_data;
 wire [15:0] fifo_data_out;
+wire [4:0] fifo_level;
+
+// Регистры генератора (адреса 2 и 3), значения после сброса повторяют
+// прежний непрерывный меандр 40 кГц
+reg        pulse_arm;
+reg [7:0]  pulse_cycles;
+reg [15:0] pulse_half_period;
+reg [31:0] pulse_prf_period;
+wire       burst_start;
+
+always @(posedge clk or negedge reset_n) begin
+  if (!reset_n) begin
+		pulse_arm <= 1'b1;
+		pulse_cycles <= 8'd0;
+		pulse_half_period <= 16'd625;
+		pulse_prf_period <= 32'd0;
+  end else if (avl_write && avl_address == 2'b10) begin
+		pulse_arm <= avl_writedata[0];
+		pulse_cycles <= avl_writedata[15:8];
+		pulse_half_period <= (avl_writedata[31:16] == 16'd0) ? 16'd1 : avl_writedata[31:16];
+  end else if (avl_write && avl_address == 2'b11) begin
+		pulse_prf_period <= avl_writedata;
+  end
+end
 
 // Генератор ультразвука
 ultrasonic_generator u_ultrasonic_gen (
     .clk(clk),
     .rst(~reset_n),          // инвертируем активный низкий сброс
-    .pulse_out(ultrasonic_pulse)
+    .arm(pulse_arm),
+    .half_period(pulse_half_period),
+    .cycles(pulse_cycles),
+    .prf_period(pulse_prf_period),
+    .pulse_out(ultrasonic_pulse),
+    .burst_start(burst_start)
 );
 
 // Память (FIFO или SRAM)
@@ -32,7 +61,8 @@ fifo_memory u_fifo_mem (
     .wr_en(write_enable),
     .data_in(echo_data),
     .rd_en(read_enable),
-    .data_out(fifo_data_out)
+    .data_out(fifo_data_out),
+    .level(fifo_level)
 );
 
 // Детектор фронта сигнала echo
@@ -68,7 +98,26 @@ end
 assign echo_data = echo_amplitude;  // Пишем в память амплитуду
 
 // Чтение данных по Avalon-MM
+// Адрес 0 — извлечение слова из FIFO, адрес 1 — состояние:
+// [4:0] число слов в FIFO, [16] было чтение пустого FIFO (сбрасывается записью 1 в бит 16)
+// Адрес 2 — управление генератором: [0] разрешение, [15:8] периодов в пачке,
+// [31:16] полупериод заполнения в тактах. Адрес 3 — период повторения пачек в тактах.
 assign read_enable = avl_read & (avl_address == 2'b00);
-assign avl_readdata = {16'd0, fifo_data_out};  // Выдаём данные на чтение
+
+reg echo_underflow;
+always @(posedge clk or negedge reset_n) begin
+  if (!reset_n) begin
+		echo_underflow <= 1'b0;
+  end else if (read_enable && fifo_level == 5'd0) begin
+		echo_underflow <= 1'b1;
+  end else if (avl_write && avl_address == 2'b01 && avl_writedata[16]) begin
+		echo_underflow <= 1'b0;
+  end
+end
+
+assign avl_readdata = (avl_address == 2'b01) ? {15'd0, echo_underflow, 11'd0, fifo_level} :  // Регистр состояния
+                      (avl_address == 2'b10) ? {pulse_half_period, pulse_cycles, 7'd0, pulse_arm} :
+                      (avl_address == 2'b11) ? pulse_prf_period :
+                      {16'd0, fifo_data_out};                                        // Выдаём данные на чтение
 
 endmodule
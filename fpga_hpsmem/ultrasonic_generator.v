@@ -1,28 +1,71 @@
 module ultrasonic_generator(
-    input wire clk,           // Тактовая частота FPGA (например, 50 МГц)
-    input wire rst,           // Асинхронный сброс
-    output reg pulse_out      // Выходной ультразвуковой сигнал (40 кГц)
+    input wire clk,                 // Тактовая частота FPGA (например, 50 МГц)
+    input wire rst,                 // Асинхронный сброс
+    input wire arm,                 // Разрешение генерации
+    input wire [15:0] half_period,  // Полупериод заполнения в тактах
+    input wire [7:0] cycles,        // Периодов в пачке, 0 — непрерывный меандр
+    input wire [31:0] prf_period,   // Период повторения пачек в тактах, 0 — без пауз
+    output reg pulse_out,           // Выходной ультразвуковой сигнал
+    output reg burst_start          // Импульс в такт начала пачки
 );
 
-    reg [15:0] counter;       // 16-битный счётчик тактов
-
-    // Период сигнала 40 кГц при тактовой частоте 50 МГц
+    // Период сигнала 40 кГц при тактовой частоте 50 МГц (значение после сброса)
     parameter PULSE_PERIOD = 1250;  // 50_000_000 / 40_000 = 1250
 
+    reg [15:0] counter;             // Счётчик тактов полупериода
+    reg [8:0]  edges;               // Оставшиеся фронты в пачке
+    reg [31:0] prf_counter;         // Счётчик тактов периода повторения
+
+    wire continuous = (cycles == 8'd0);
+    wire prf_tick = (prf_period == 32'd0) ? (edges == 9'd0) : (prf_counter == 32'd0);
+
     always @(posedge clk or posedge rst) begin
         if (rst) begin
-            // Сброс: обнуляем счётчик и выход
+            // Сброс: обнуляем счётчики и выход
+            counter <= 16'd0;
+            edges <= 9'd0;
+            prf_counter <= 32'd0;
+            pulse_out <= 1'b0;
+            burst_start <= 1'b0;
+        end else if (!arm) begin
+            // Генератор выключен: выход в нуле, следующая пачка начнётся сразу после включения
             counter <= 16'd0;
+            edges <= 9'd0;
+            prf_counter <= 32'd0;
             pulse_out <= 1'b0;
+            burst_start <= 1'b0;
         end else begin
-            // Если прошло половина периода — инвертируем сигнал
-            if (counter >= (PULSE_PERIOD / 2)) begin
-                pulse_out <= ~pulse_out;  // Инвертировать выход
-                counter <= 16'd0;         // Сбросить счётчик
+            burst_start <= 1'b0;
+
+            if (prf_period != 32'd0) begin
+                prf_counter <= (prf_counter >= prf_period - 1) ? 32'd0 : prf_counter + 1;
+            end
+
+            if (continuous) begin
+                // Если прошла половина периода — инвертируем сигнал
+                if (counter >= half_period - 1) begin
+                    pulse_out <= ~pulse_out;
+                    counter <= 16'd0;
+                end else begin
+                    counter <= counter + 1;
+                end
+            end else if (edges == 9'd0) begin
+                // Пауза между пачками
+                pulse_out <= 1'b0;
+                if (prf_tick) begin
+                    edges <= {cycles, 1'b0};  // два фронта на период
+                    counter <= 16'd0;
+                    pulse_out <= 1'b1;
+                    burst_start <= 1'b1;
+                end
+            end else if (counter >= half_period - 1) begin
+                counter <= 16'd0;
+                edges <= edges - 1;
+                pulse_out <= (edges == 9'd1) ? 1'b0 : ~pulse_out;
             end else begin
-                counter <= counter + 1;   // Увеличить счётчик
+                counter <= counter + 1;
             end
         end
     end
 
-endmodule
\ No newline at end of file
+endmodule
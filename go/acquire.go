@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"fpga-ultrasound-go/fpga"
+	"fpga-ultrasound-go/memory"
+	"fpga-ultrasound-go/storage"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// windowFlags — окно захвата в памяти FPGA (acquire, info).
+type windowFlags struct {
+	base      int64
+	frameSize int
+	encoding  string
+	fullScale float64
+	header    int
+	pingPong  bool
+	sopcinfo  string
+	region    string
+}
+
+func addWindowFlags(fs *flag.FlagSet) *windowFlags {
+	f := &windowFlags{}
+	fs.Int64Var(&f.base, "base", memory.DefaultBaseAddress, "физический адрес окна захвата")
+	fs.IntVar(&f.frameSize, "frame-size", memory.DefaultFrameSize, "число отсчётов в кадре")
+	fs.StringVar(&f.encoding, "encoding", string(memory.EncodingU16), "формат отсчётов: u16, s16, u12l, p2x16")
+	fs.Float64Var(&f.fullScale, "full-scale", memory.DefaultFullScale, "размах входа АЦП, В")
+	fs.IntVar(&f.header, "header-bytes", 0, "размер заголовка кадра; первое слово — аппаратный счётчик кадров")
+	fs.BoolVar(&f.pingPong, "ping-pong", false, "окно из двух буферов с передачей через слова состояния (devmem, uio)")
+	fs.StringVar(&f.sopcinfo, "sopcinfo", "", "файл .sopcinfo с картой памяти Qsys")
+	fs.StringVar(&f.region, "region", "", "ведомый интерфейс окна захвата из .sopcinfo, например onchip_memory2_0.s1")
+	return f
+}
+
+func (f *windowFlags) window() memory.Window {
+	return memory.Window{
+		BaseAddress:    f.base,
+		FrameSize:      f.frameSize,
+		Encoding:       memory.Encoding(f.encoding),
+		FullScaleVolts: f.fullScale,
+		HeaderBytes:    f.header,
+		PingPong:       f.pingPong,
+	}
+}
+
+// pipelineFlags — настройки обработки и стадий конвейера (acquire, replay).
+type pipelineFlags struct {
+	configFile      string
+	settings        *Settings
+	shutdownTimeout time.Duration
+	calFile         string
+	calDir          string
+	board           string
+	serial          string
+	channels        int
+	interleave      string
+	chanGain        string
+	chanOffset      string
+	avgMode         string
+	avgN            int
+	avgAlpha        float64
+	avgJitter       int
+	health          string
+}
+
+func addPipelineFlags(fs *flag.FlagSet) *pipelineFlags {
+	f := &pipelineFlags{settings: settingsFlags(fs)}
+	fs.StringVar(&f.configFile, "config", "", "файл настроек обработки (JSON); переопределяется переменными ULTRASOUND_* и флагами")
+	fs.DurationVar(&f.shutdownTimeout, "shutdown-timeout", 10*time.Second, "время на обработку принятых кадров после SIGINT/SIGTERM")
+	fs.StringVar(&f.calFile, "calibration", "", "файл калибровки АЦП (JSON)")
+	fs.StringVar(&f.calDir, "calibration-dir", "calibration", "каталог калибровок <плата>/<серийный номер>.json")
+	fs.StringVar(&f.board, "board", "", "модель платы для поиска калибровки")
+	fs.StringVar(&f.serial, "serial", "", "серийный номер платы для поиска калибровки")
+	fs.IntVar(&f.channels, "channels", 1, "число приёмных каналов, чередующихся в окне")
+	fs.StringVar(&f.interleave, "interleave", string(memory.InterleaveSample), "чередование каналов: sample или block")
+	fs.StringVar(&f.chanGain, "channel-gain", "", "усиление каналов через запятую (пусто — 1)")
+	fs.StringVar(&f.chanOffset, "channel-offset", "", "смещение нуля каналов через запятую, В")
+	fs.StringVar(&f.avgMode, "average", "", "накопление кадров: mean, exp, median (пусто — без накопления)")
+	fs.IntVar(&f.avgN, "average-n", memory.DefaultAverageConfig().N, "глубина накопления, кадры")
+	fs.Float64Var(&f.avgAlpha, "average-alpha", 0, "коэффициент экспоненциального среднего (0 — 2/(N+1))")
+	fs.IntVar(&f.avgJitter, "average-jitter", memory.DefaultAverageConfig().MaxJitter, "допустимое смещение зондирующего импульса, отсчёты")
+	fs.StringVar(&f.health, "health", "", "действия проверок кадра через запятую, например stuck=halt,noise=off (log, drop, halt, off)")
+	return f
+}
+
+// registerFlags — регистры ведомого fpga.v за мостом lightweight.
+type registerFlags struct {
+	dev    string
+	offset int64
+}
+
+func addRegisterFlags(fs *flag.FlagSet, help string) *registerFlags {
+	f := &registerFlags{}
+	fs.StringVar(&f.dev, "pulser", "", help)
+	fs.Int64Var(&f.offset, "pulser-offset", 0, "смещение ведомого fpga.v от начала моста lightweight")
+	return f
+}
+
+// open отображает регистры или создаёт программную модель для dev == "sim".
+// Без write регистры отображаются только для чтения.
+func (f *registerFlags) open(write bool) (fpga.Registers, func(), error) {
+	if f.dev == "sim" {
+		return fpga.NewSimulatedRegisters(), func() {}, nil
+	}
+	openRegisters := fpga.OpenRegistersReadOnly
+	if write {
+		openRegisters = fpga.OpenRegisters
+	}
+	mapped, err := openRegisters(f.dev, f.offset, fpga.EchoRegisterCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mapped, func() { mapped.Close() }, nil
+}
+
+// acquireFlags — источник кадров, запись сессии и генератор.
+type acquireFlags struct {
+	window     *windowFlags
+	pipeline   *pipelineFlags
+	registers  *registerFlags
+	source     string
+	path       string
+	uioMap     int
+	loop       bool
+	sourceID   string
+	simNoise   float64
+	simBits    int
+	simDrop    float64
+	simSeed    int64
+	record     string
+	irqPath    string
+	irqTimeout time.Duration
+	poll       time.Duration
+	stats      time.Duration
+	burstFreq  float64
+	cycles     int
+	prf        float64
+}
+
+func addAcquireFlags(fs *flag.FlagSet) *acquireFlags {
+	f := &acquireFlags{
+		window:    addWindowFlags(fs),
+		pipeline:  addPipelineFlags(fs),
+		registers: addRegisterFlags(fs, "регистры генератора: /dev/mem или sim (программная модель); пусто — генератор не настраивается"),
+	}
+	fs.StringVar(&f.source, "source", memory.SourceDevMem, "источник кадров: devmem, uio, file, synthetic")
+	fs.StringVar(&f.path, "path", "", "устройство (/dev/mem, /dev/uio0) или файл отсчётов для source=file")
+	fs.IntVar(&f.uioMap, "uio-map", 0, "номер области mapN устройства UIO")
+	fs.BoolVar(&f.loop, "loop", false, "воспроизводить файл по кругу")
+	fs.StringVar(&f.sourceID, "source-id", "", "идентификатор источника в выходных файлах (по умолчанию источник:путь)")
+	fs.Float64Var(&f.simNoise, "sim-noise", memory.DefaultSimConfig().NoiseRMS, "synthetic: СКЗ шума, В")
+	fs.IntVar(&f.simBits, "sim-bits", memory.DefaultSimConfig().ADCBits, "synthetic: разрядность модели АЦП")
+	fs.Float64Var(&f.simDrop, "sim-drop", 0, "synthetic: вероятность пропуска кадра")
+	fs.Int64Var(&f.simSeed, "sim-seed", 0, "synthetic: зерно генератора шума (0 — случайное)")
+	fs.StringVar(&f.record, "record", "", "записать сессию в двоичный файл для последующего воспроизведения (replay)")
+	fs.StringVar(&f.irqPath, "irq", "", "устройство UIO, прерывание которого сообщает о готовности кадра (например, /dev/uio1)")
+	fs.DurationVar(&f.irqTimeout, "irq-timeout", time.Second, "предельное ожидание прерывания")
+	fs.DurationVar(&f.poll, "poll", 0, "период опроса без прерываний; по умолчанию — длительность кадра при source_rate_hz, 0 — читать подряд")
+	fs.DurationVar(&f.stats, "stats", 10*time.Second, "период записи в лог горутин и памяти процесса (0 — не записывать)")
+	fs.Float64Var(&f.burstFreq, "burst-freq", fpga.DefaultBurstFreqHz, "частота заполнения пачки, Гц")
+	fs.IntVar(&f.cycles, "cycles", 0, "периодов в пачке (0 — непрерывный меандр)")
+	fs.Float64Var(&f.prf, "prf", 0, "частота повторения пачек, Гц (0 — без пауз)")
+	return f
+}
+
+// runAcquire — команда acquire: сбор кадров с устройства (или модели)
+// с обработкой блоков и необязательной записью сессии.
+func runAcquire(args []string) int {
+	fs := newFlagSet("acquire", "")
+	f := addAcquireFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: %q", fs.Args())
+	}
+	if f.source == memory.SourceSession {
+		return usageError(fs, "session files are played back by the replay command")
+	}
+
+	logFile, err := logSettings()
+	if err != nil {
+		log.Print(err)
+	}
+	defer logFile.Close()
+
+	settings, err := loadRunSettings(f.pipeline.configFile, fs, f.pipeline.settings)
+	if err != nil {
+		return fail("Settings error: %v", err)
+	}
+
+	statsCtx, stopStats := context.WithCancel(context.Background())
+	defer stopStats()
+	if f.stats > 0 {
+		go logRuntimeStats(statsCtx, f.stats)
+	}
+	log.Println("🚀 Starting FPGA Ultrasound Data Collector...")
+
+	// Модель оцифровывается с той же частотой, с которой отсчёты обрабатываются.
+	sim := memory.DefaultSimConfig()
+	sim.ADCRateHz = settings.SampleRateHz
+	sim.NoiseRMS = f.simNoise
+	sim.ADCBits = f.simBits
+	sim.DropProbability = f.simDrop
+	sim.Seed = f.simSeed
+
+	params := processingParams{Settings: settings, LowHz: settings.LowCutoffHz, HighHz: settings.HighCutoffHz}
+	if f.registers.dev != "" {
+		active, closePulser, err := f.setupPulser()
+		if err != nil {
+			return fail("Pulser error: %v", err)
+		}
+		defer closePulser()
+		// Полоса фильтра и модель эха следуют за действующими параметрами генератора.
+		params.LowHz, params.HighHz = active.Band()
+		params.CenterHz = active.BurstFreqHz
+		if err := params.checkBand(); err != nil {
+			return fail("Pulser error: %v", err)
+		}
+		sim.BurstFreqHz = active.BurstFreqHz
+		if active.Cycles > 0 {
+			sim.BurstCycles = float64(active.Cycles)
+		}
+		log.Printf("Генератор: %.0f Гц, периодов %d, PRF %.1f Гц, включён: %t; полоса обработки %.0f–%.0f Гц",
+			active.BurstFreqHz, active.Cycles, active.PRFHz, active.Armed, params.LowHz, params.HighHz)
+	}
+
+	cfg := memory.Config{
+		Source:       f.source,
+		Path:         f.path,
+		UIOMap:       f.uioMap,
+		Window:       f.window.window(),
+		Sopcinfo:     f.window.sopcinfo,
+		Region:       f.window.region,
+		SampleRateHz: settings.SourceRateHz,
+		Loop:         f.loop,
+		Sim:          sim,
+		IRQPath:      f.irqPath,
+		IRQTimeout:   f.irqTimeout,
+		PollInterval: f.poll,
+	}
+	if !isFlagSet(fs, "poll") {
+		cfg.PollInterval = framePeriod(cfg.Window.FrameSize, settings.SourceRateHz)
+	}
+	id := f.sourceID
+	if id == "" {
+		id = f.source
+		if f.path != "" {
+			id += ":" + f.path
+		}
+	}
+	return runPipeline(f.pipeline, params, cfg, id, f.record)
+}
+
+// setupPulser применяет параметры генератора из флагов и возвращает
+// фактически установленные вместе с функцией освобождения регистров.
+func (f *acquireFlags) setupPulser() (fpga.PulserSettings, func(), error) {
+	regs, closeRegs, err := f.registers.open(true)
+	if err != nil {
+		return fpga.PulserSettings{}, nil, err
+	}
+	active, err := fpga.NewPulser(regs).Configure(fpga.PulserSettings{
+		BurstFreqHz: f.burstFreq,
+		Cycles:      f.cycles,
+		PRFHz:       f.prf,
+		Armed:       true,
+	})
+	if err != nil {
+		closeRegs()
+		return fpga.PulserSettings{}, nil, err
+	}
+	return active, closeRegs, nil
+}
+
+// framePeriod возвращает длительность кадра из frameSize отсчётов при частоте
+// rateHz: чаще опрашивать окно бессмысленно, FPGA не успеет его обновить.
+func framePeriod(frameSize int, rateHz float64) time.Duration {
+	if rateHz <= 0 {
+		return 0
+	}
+	return time.Duration(float64(frameSize) / rateHz * float64(time.Second))
+}
+
+// logRuntimeStats раз в interval записывает в лог число горутин и память
+// процесса, пока не отменён ctx.
+func logRuntimeStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var m runtime.MemStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runtime.ReadMemStats(&m)
+			log.Printf("Горутин: %d, занято памяти: %d Б, выделено за запуск: %d Б, получено от ОС: %d Б, CPU: %d",
+				runtime.NumGoroutine(), m.Alloc, m.TotalAlloc, m.Sys, runtime.NumCPU())
+		}
+	}
+}
+
+// runReplay — команда replay: кадры записанной сессии проходят тот же
+// конвейер, что и при сборе. Окно и формат отсчётов берутся из заголовка записи.
+func runReplay(args []string) int {
+	fs := newFlagSet("replay", "<файл сессии>")
+	pf := addPipelineFlags(fs)
+	mode := fs.String("replay", string(memory.ReplayRealtime), "темп воспроизведения: realtime, fast или step")
+	loop := fs.Bool("loop", false, "воспроизводить сессию по кругу")
+	sourceID := fs.String("source-id", "", "идентификатор источника в выходных файлах (по умолчанию session:путь)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		return usageError(fs, "replay takes exactly one session file, got %d arguments", fs.NArg())
+	}
+	switch memory.ReplayMode(*mode) {
+	case memory.ReplayRealtime, memory.ReplayFast, memory.ReplayStep:
+	default:
+		return usageError(fs, "unknown replay mode %q, want realtime, fast or step", *mode)
+	}
+	path := fs.Arg(0)
+
+	logFile, err := logSettings()
+	if err != nil {
+		log.Print(err)
+	}
+	defer logFile.Close()
+
+	settings, err := loadRunSettings(pf.configFile, fs, pf.settings)
+	if err != nil {
+		return fail("Settings error: %v", err)
+	}
+
+	reader, err := memory.OpenSession(path)
+	if err != nil {
+		return fail("Session open error: %v", err)
+	}
+	info := reader.Info()
+	reader.Close()
+	log.Printf("Воспроизведение сессии %s: источник %s, начало %s, версия %d",
+		path, info.SourceID, info.Started.Format(time.RFC3339), info.Version)
+
+	// Окно в заголовке уже разрешено по .sopcinfo при записи.
+	cfg := info.Config
+	cfg.Source = memory.SourceSession
+	cfg.Path = path
+	cfg.Sopcinfo, cfg.Region = "", ""
+	cfg.Loop = *loop
+	cfg.Replay = memory.ReplayMode(*mode)
+	if cfg.Replay == memory.ReplayStep {
+		cfg.Step = stepper()
+	}
+	id := *sourceID
+	if id == "" {
+		id = memory.SourceSession + ":" + path
+	}
+	params := processingParams{Settings: settings, LowHz: settings.LowCutoffHz, HighHz: settings.HighCutoffHz}
+	return runPipeline(pf, params, cfg, id, "")
+}
+
+// runPipeline открывает источник cfg, собирает стадии конвейера и обрабатывает
+// кадры до исчерпания источника, ошибки или сигнала. SIGINT и SIGTERM
+// останавливают сбор; принятые кадры дообрабатываются не дольше
+// f.shutdownTimeout. Возвращает код завершения по причине остановки.
+func runPipeline(f *pipelineFlags, params processingParams, cfg memory.Config, id, record string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	started := time.Now()
+
+	source, err := memory.NewSource(cfg)
+	if err != nil {
+		return fail("Frame source error: %v", err)
+	}
+	tracker := memory.NewTracker(source, id)
+	if err := tracker.Open(ctx); err != nil {
+		return fail("Frame source open error: %v", err)
+	}
+	defer tracker.Close()
+	log.Printf("Источник кадров: %s", id)
+
+	var recorder *memory.Recorder
+	if record != "" {
+		recorder, err = memory.NewRecorder(record, id, cfg)
+		if err != nil {
+			return fail("Session record error: %v", err)
+		}
+		log.Printf("Запись сессии: %s", record)
+	}
+
+	decoder, err := memory.NewDecoder(cfg.Window.Encoding, cfg.Window.FullScaleVolts)
+	if err != nil {
+		return fail("Sample encoding error: %v", err)
+	}
+	healthCfg := memory.DefaultHealthConfig()
+	if err := memory.ParseHealthActions(&healthCfg, f.health); err != nil {
+		return fail("Health check config error: %v", err)
+	}
+	monitor := memory.NewHealthMonitor(healthCfg, decoder)
+
+	calibration, err := f.loadCalibration()
+	if err != nil {
+		return fail("Calibration error: %v", err)
+	}
+	params.Unit, params.FullScale = memory.UnitADCVolts, cfg.Window.FullScaleVolts
+	if calibration != nil {
+		params.Unit, params.FullScale = memory.UnitVolts, calibration.FullScale(decoder)
+		log.Printf("Калибровка: плата %q, серийный номер %q, усиление приёмника %.1f дБ",
+			calibration.Board, calibration.Serial, calibration.ReceiverGainDB)
+	}
+	log.Printf("Единицы отсчётов: %s, полная шкала %g %s", params.Unit, params.FullScale, params.Unit)
+
+	layout, err := f.channelLayout(cfg.Window.FrameSize)
+	if err != nil {
+		return fail("Channel layout error: %v", err)
+	}
+	if len(layout.Channels) > 1 {
+		log.Printf("Каналов: %d, чередование: %s", len(layout.Channels), layout.Interleave)
+	}
+
+	var averager *memory.Averager
+	if f.avgMode != "" {
+		avgCfg := memory.DefaultAverageConfig()
+		avgCfg.Mode = memory.AverageMode(f.avgMode)
+		avgCfg.N = f.avgN
+		avgCfg.Alpha = f.avgAlpha
+		avgCfg.MaxJitter = f.avgJitter
+		averager, err = memory.NewAverager(avgCfg)
+		if err != nil {
+			return fail("Averaging config error: %v", err)
+		}
+		log.Printf("Накопление кадров: %s, N=%d", avgCfg.Mode, avgCfg.N)
+	}
+
+	// Очередь ограничена: при медленной обработке действует политика settings.Backpressure.
+	queue, err := newBlockQueue(params.QueueDepth, Backpressure(params.Backpressure))
+	if err != nil {
+		return fail("Processing queue error: %v", err)
+	}
+	p := &pipeline{
+		tracker:     tracker,
+		recorder:    recorder,
+		monitor:     monitor,
+		decoder:     decoder,
+		calibration: calibration,
+		averager:    averager,
+		layout:      layout,
+
+		queue:        queue,
+		blockSize:    params.FFTKernelSize,
+		minBlock:     params.FIRKernelSize,
+		sampleRateHz: params.SampleRateHz,
+	}
+	go p.acquire(ctx)
+
+	// Обработка живёт дольше сбора: после сигнала она разбирает очередь,
+	// пока не истечёт shutdown-timeout.
+	drainCtx, cancelDrain := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelDrain()
+	processed := make(chan struct{})
+	go func() {
+		defer close(processed)
+		p.process(drainCtx, params)
+	}()
+
+	log.Println("Сбор данных")
+	select {
+	case <-processed:
+	case <-ctx.Done():
+		// Повторный сигнал завершает процесс сразу.
+		stop()
+		log.Printf("Получен сигнал остановки, завершение обработки (не дольше %v)", f.shutdownTimeout)
+		select {
+		case <-processed:
+		case <-time.After(f.shutdownTimeout):
+			log.Printf("⚠️ Обработка не завершилась за %v, оставшиеся блоки пропущены", f.shutdownTimeout)
+			cancelDrain()
+			<-processed
+		}
+	}
+	log.Println("Обработка завершена")
+
+	summary := p.summary(id, started)
+	log.Printf("Итоги: источник %s, %s, остановка: %s; кадров %d, потеряно %d, отброшено проверками %d; блоков обработано %d, с ошибкой %d, выброшено %d, пропущено %d",
+		summary.Source, summary.Duration, summary.StopReason, summary.Frames, summary.Dropped, summary.HealthDropped,
+		summary.Blocks, summary.BlocksFailed, summary.BlocksDropped, summary.BlocksSkipped)
+	if err := storage.SaveJSON("./"+SummaryFile, summary); err != nil {
+		log.Printf("❌ summary save error: %v", err)
+	}
+	switch summary.StopReason {
+	case stopHealth:
+		return exitHalted
+	case stopError:
+		return exitFailure
+	}
+	return exitOK
+}
+
+// channelLayout собирает раскладку каналов из флагов.
+func (f *pipelineFlags) channelLayout(frameSize int) (memory.ChannelLayout, error) {
+	layout := memory.NewChannelLayout(f.channels, memory.Interleave(f.interleave))
+	gains, err := parseFloats(f.chanGain, f.channels)
+	if err != nil {
+		return layout, fmt.Errorf("channel gain: %w", err)
+	}
+	offsets, err := parseFloats(f.chanOffset, f.channels)
+	if err != nil {
+		return layout, fmt.Errorf("channel offset: %w", err)
+	}
+	for i := range layout.Channels {
+		if gains != nil {
+			layout.Channels[i].Gain = gains[i]
+		}
+		if offsets != nil {
+			layout.Channels[i].Offset = offsets[i]
+		}
+	}
+	return layout, layout.Validate(frameSize)
+}
+
+// loadCalibration читает калибровку из файла или из каталога по плате и серийному номеру.
+// Без них возвращает nil: отсчёты остаются в номинальных вольтах АЦП.
+func (f *pipelineFlags) loadCalibration() (*memory.Calibration, error) {
+	switch {
+	case f.calFile != "":
+		return memory.ReadCalibration(f.calFile)
+	case f.board != "":
+		return memory.LoadCalibration(f.calDir, f.board, f.serial)
+	default:
+		return nil, nil
+	}
+}
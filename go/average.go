@@ -4,52 +4,95 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 )
 
-const PATH = "B:/Измерение_зависимости_отклика_от_растояния_излучателя"
+// notANumber — пустое значение в CSV-выгрузках измерений.
+const notANumber = "не число"
 
 type Measurement struct {
 	Name      string
 	Columns   []string    // Заголовки столбцов
 	Points    []string    // Единицы измерения
-	AvgData   [][]float64 // Усредненные данные
+	AvgData   [][]float64 // Усредненные данные; NaN — ни в одном файле нет числа
 	FileCount int         // Количество обработанных файлов
 }
 
-func main() {
-	rootDir := PATH
+// runAverage — команда average: для каждого каталога с CSV-файлами внутри
+// корневого пишет <имя каталога>.csv с поэлементным средним.
+func runAverage(args []string) int {
+	fs := newFlagSet("average", "<корневой каталог>")
+	out := fs.String("out", "", "каталог результатов (по умолчанию корневой)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		return usageError(fs, "average takes exactly one root directory, got %d arguments", fs.NArg())
+	}
+	rootDir := fs.Arg(0)
+	outDir := *out
+	if outDir == "" {
+		outDir = rootDir
+	}
+
 	measurements, err := ProcessRootDir(rootDir)
 	if err != nil {
-		log.Fatalf("Error processing directory: %v", err)
+		return fail("Error processing directory: %v", err)
+	}
+	if len(measurements) == 0 {
+		return fail("no directories with CSV files in %s", rootDir)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fail("Error creating %s: %v", outDir, err)
 	}
-
-	// Выводим результаты
 	for _, m := range measurements {
-		file, err := os.Create(PATH + "/" + m.Name + ".csv")
-		if err != nil {
-			log.Fatalf("Error opening file: %v", err)
+		path := filepath.Join(outDir, m.Name+".csv")
+		if err := writeMeasurement(path, m); err != nil {
+			return fail("Error writing %s: %v", path, err)
 		}
-		defer file.Close()
+		fmt.Printf("%s: файлов %d, строк %d → %s\n", m.Name, m.FileCount, len(m.AvgData), path)
+	}
+	return exitOK
+}
 
-		m.Columns[3] = strings.ReplaceAll(m.Columns[3], ";", ",")
+// writeMeasurement пишет заголовки, единицы и усреднённые строки через ';'.
+func writeMeasurement(path string, m *Measurement) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-		file.WriteString(fmt.Sprintf(strings.Join(m.Columns, ";")) + "\n")
-		file.WriteString(fmt.Sprintf(strings.Join(m.Points, ";")) + "\n")
-		for row := range m.AvgData {
-			file.WriteString(
-				fmt.Sprintf("%v;%v;%v;%v\n",
-					m.AvgData[row][0],
-					m.AvgData[row][1],
-					m.AvgData[row][2],
-					m.AvgData[row][3],
-				))
+	// Значения пишутся без кавычек, поэтому ';' в заголовках заменяется.
+	columns := make([]string, len(m.Columns))
+	for i, c := range m.Columns {
+		columns[i] = strings.ReplaceAll(c, ";", ",")
+	}
+	if _, err := file.WriteString(strings.Join(columns, ";") + "\n"); err != nil {
+		return err
+	}
+	if _, err := file.WriteString(strings.Join(m.Points, ";") + "\n"); err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(m.Columns))
+	for _, row := range m.AvgData {
+		fields = fields[:0]
+		for _, v := range row {
+			if math.IsNaN(v) {
+				fields = append(fields, notANumber)
+			} else {
+				fields = append(fields, fmt.Sprint(v))
+			}
+		}
+		if _, err := file.WriteString(strings.Join(fields, ";") + "\n"); err != nil {
+			return err
 		}
 	}
+	return file.Close()
 }
 
 // processRootDir обрабатывает корневую директорию
@@ -100,7 +143,9 @@ func ProcessRootDir(rootPath string) ([]*Measurement, error) {
 	return measurements, nil
 }
 
-func readCSV(filePath string) ([][]float64, []string, []string, error) {
+// readMeasurementCSV читает выгрузку измерения: заголовки, единицы и строки
+// чисел с десятичной запятой. Значения "не число" возвращаются как NaN.
+func readMeasurementCSV(filePath string) ([][]float64, []string, []string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, nil, nil, err
@@ -133,7 +178,8 @@ func readCSV(filePath string) ([][]float64, []string, []string, error) {
 
 		row := make([]float64, len(headers))
 		for i, v := range record {
-			if v == "не число" {
+			if v == notANumber {
+				row[i] = math.NaN()
 				continue
 			}
 			v = strings.ReplaceAll(v, ",", ".")
@@ -149,7 +195,8 @@ func readCSV(filePath string) ([][]float64, []string, []string, error) {
 	return data, headers, points, nil
 }
 
-// processMeasurementDir обрабатывает директорию с измерениями
+// processMeasurementDir обрабатывает директорию с измерениями.
+// Среднее считается по файлам, в которых значение — число.
 func processMeasurementDir(dirPath string) (*Measurement, error) {
 	files, err := os.ReadDir(dirPath)
 	if err != nil {
@@ -168,13 +215,13 @@ func processMeasurementDir(dirPath string) (*Measurement, error) {
 	}
 
 	var sumData [][]float64
+	var counts [][]int
 	var headers []string
 	var points []string
-	var totalRows int
 
 	// Читаем и суммируем данные из всех CSV файлов
 	for i, file := range csvFiles {
-		data, h, p, err := readCSV(file)
+		data, h, p, err := readMeasurementCSV(file)
 		if err != nil {
 			return nil, fmt.Errorf("error reading %s: %v", file, err)
 		}
@@ -183,28 +230,26 @@ func processMeasurementDir(dirPath string) (*Measurement, error) {
 		if i == 0 {
 			headers = h
 			points = p
+			sumData = make([][]float64, len(data))
+			counts = make([][]int, len(data))
+			for j := range data {
+				sumData[j] = make([]float64, len(headers))
+				counts[j] = make([]int, len(headers))
+			}
 		} else if !compareHeaders(headers, h) {
 			return nil, fmt.Errorf("headers mismatch between files in %s", dirPath)
 		}
 
-		// Инициализируем sumData при первом проходе
-		if i == 0 {
-			sumData = make([][]float64, len(data))
-			for j := range data {
-				sumData[j] = make([]float64, len(data[j]))
-				copy(sumData[j], data[j])
-			}
-			totalRows = len(data)
-		} else {
-			// Проверяем, что количество строк совпадает
-			if len(data) != totalRows {
-				totalRows = min(totalRows, len(data))
-				sumData = sumData[:totalRows]
-			}
-			// Суммируем данные
-			for j := range totalRows {
-				for k := range data[j] {
-					sumData[j][k] += data[j][k]
+		// Строки сверх самого короткого файла отбрасываются
+		if len(data) < len(sumData) {
+			sumData = sumData[:len(data)]
+			counts = counts[:len(data)]
+		}
+		for j := range sumData {
+			for k, v := range data[j] {
+				if !math.IsNaN(v) {
+					sumData[j][k] += v
+					counts[j][k]++
 				}
 			}
 		}
@@ -212,9 +257,14 @@ func processMeasurementDir(dirPath string) (*Measurement, error) {
 
 	// Вычисляем средние значения
 	avgData := make([][]float64, len(sumData))
-	for i := range len(sumData) {
-		for j := range sumData[0] {
-			avgData[i] = append(avgData[i], sumData[i][j]/float64(len(files)))
+	for i := range sumData {
+		avgData[i] = make([]float64, len(sumData[i]))
+		for j := range sumData[i] {
+			if counts[i][j] == 0 {
+				avgData[i][j] = math.NaN()
+				continue
+			}
+			avgData[i][j] = sumData[i][j] / float64(counts[i][j])
 		}
 	}
 
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fpga-ultrasound-go/memory"
+	"fpga-ultrasound-go/storage"
+)
+
+// quietStdio отправляет stdout и stderr команд в /dev/null.
+func quietStdio(t *testing.T) {
+	t.Helper()
+	quietLog(t)
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = null, null
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		null.Close()
+	})
+}
+
+func TestRunExitCodes(t *testing.T) {
+	quietStdio(t)
+	t.Chdir(t.TempDir())
+
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{nil, exitUsage},
+		{[]string{"help"}, exitOK},
+		{[]string{"bogus"}, exitUsage},
+		{[]string{"acquire", "-h"}, exitOK},
+		{[]string{"acquire", "-bogus"}, exitUsage},
+		{[]string{"acquire", "extra"}, exitUsage},
+		{[]string{"acquire", "-source", memory.SourceSession}, exitUsage},
+		{[]string{"-bogus"}, exitUsage}, // флаги без команды — acquire
+		{[]string{"replay"}, exitUsage},
+		{[]string{"replay", "-replay", "slow", "s.bin"}, exitUsage},
+		{[]string{"replay", "-replay", "fast", "missing.bin"}, exitFailure},
+		{[]string{"process"}, exitUsage},
+		{[]string{"process", "missing.csv"}, exitFailure},
+		{[]string{"process", "-fir-kernel-size", "4", "missing.csv"}, exitFailure},
+		{[]string{"average"}, exitUsage},
+		{[]string{"average", "."}, exitFailure},
+		{[]string{"info", "-region", "onchip_memory2_0.s1"}, exitUsage},
+		{[]string{"info", "-frame-size", "0"}, exitFailure},
+	} {
+		if got := run(tc.args, os.Stderr); got != tc.want {
+			t.Errorf("run %q = %d, want %d", tc.args, got, tc.want)
+		}
+	}
+}
+
+// Кадры writeSamples по 256 отсчётов на середине шкалы: зондирующая пачка
+// из трёх периодов по 10 отсчётов в начале кадра и такое же эхо на отсчёте
+// sampleEchoAt. При частоте дискретизации по умолчанию (1 МГц) время
+// пролёта — 150 мкс с допуском на длительность пачки.
+const (
+	sampleEchoAt     = 150
+	sampleEchoLength = 30
+)
+
+// writeSamples пишет n отсчётов таких кадров в формате SaveSample.
+func writeSamples(t *testing.T, name string, n int) {
+	t.Helper()
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = 0.5
+		for _, at := range []int{0, sampleEchoAt} {
+			if k := i%256 - at; k >= 0 && k < sampleEchoLength {
+				hann := 0.5 - 0.5*math.Cos(2*math.Pi*float64(k)/sampleEchoLength)
+				data[i] += 0.4 * hann * math.Sin(2*math.Pi*float64(k)/10)
+			}
+		}
+	}
+	if err := storage.SaveSample(name, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkTimeOfFlight проверяет, что в каждой строке результатов время
+// пролёта соответствует эху writeSamples.
+func checkTimeOfFlight(t *testing.T, rows [][]string) {
+	t.Helper()
+	sampleRate := DefaultSettings().SampleRateHz
+	want, tolerance := sampleEchoAt/sampleRate, sampleEchoLength/sampleRate
+	for i, row := range rows[1:] {
+		tof, err := strconv.ParseFloat(row[3], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(tof-want) > tolerance {
+			t.Errorf("row %d: time of flight %g s, want %g ± %g s", i+1, tof, want, tolerance)
+		}
+	}
+}
+
+func readSummary(t *testing.T, name string) runSummary {
+	t.Helper()
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s runSummary
+	if err := json.Unmarshal(raw, &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestAcquireRecordAndReplay(t *testing.T) {
+	quietStdio(t)
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	const frames, frameSize = 8, 256
+	writeSamples(t, "samples.csv", frames*frameSize)
+	code := run([]string{"acquire",
+		"-source", memory.SourceFile, "-path", "samples.csv", "-frame-size", fmt.Sprint(frameSize),
+		"-source-rate-hz", "1e8", "-record", "s.bin",
+	}, os.Stderr)
+	if code != exitOK {
+		t.Fatalf("acquire exit code %d", code)
+	}
+	acquired := readSummary(t, SummaryFile)
+	if acquired.StopReason != stopEOF || acquired.Frames != frames || acquired.Recorded != frames {
+		t.Fatalf("acquire summary %+v", acquired)
+	}
+
+	if err := os.Mkdir("replay", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(filepath.Join(dir, "replay"))
+	if code := run([]string{"replay", "-replay", "fast", "../s.bin"}, os.Stderr); code != exitOK {
+		t.Fatalf("replay exit code %d", code)
+	}
+	replayed := readSummary(t, SummaryFile)
+	if replayed.StopReason != stopEOF || replayed.Frames != frames || replayed.Blocks != acquired.Blocks {
+		t.Fatalf("replay summary %+v, acquired %+v", replayed, acquired)
+	}
+	if rows := readCSV(t, FileWithTime+"_TimeOfFlight.csv"); len(rows) != int(acquired.Blocks)+1 {
+		t.Errorf("ToF has %d rows, want header and %d blocks", len(rows), acquired.Blocks)
+	} else {
+		checkTimeOfFlight(t, rows)
+	}
+	if rows := readCSV(t, FileWithTime+"_RAW_result.csv"); len(rows) != frames*frameSize {
+		t.Errorf("raw csv has %d rows, want %d", len(rows), frames*frameSize)
+	}
+}
+
+func TestProcessCommand(t *testing.T) {
+	quietStdio(t)
+	t.Chdir(t.TempDir())
+
+	writeSamples(t, "a.csv", 2048)
+	writeSamples(t, "b.csv", 1024)
+	writeSamples(t, "short.csv", 10)
+	if code := run([]string{"process", "a.csv", "b.csv", "short.csv"}, os.Stderr); code != exitOK {
+		t.Fatalf("process exit code %d", code)
+	}
+	rows := readCSV(t, FileWithTime+"_TimeOfFlight.csv")
+	if len(rows) != 3 || rows[1][0] != "1" || rows[1][2] != "a" || rows[2][0] != "2" || rows[2][2] != "b" {
+		t.Fatalf("ToF rows %q, want files a and b", rows)
+	}
+	checkTimeOfFlight(t, rows)
+	if got := readCSV(t, FileWithTime+"_FIR_result_a.csv"); len(got) != 2048 {
+		t.Errorf("FIR result of a has %d rows, want 2048", len(got))
+	}
+	// АЧХ — характеристика ядра на сетке настроек, а не спектр сигнала.
+	afc := readCSV(t, FileWithFreq+"_filter_frequency_response_a.csv")
+	if len(afc) != DefaultSettings().AFCPoints+1 || len(afc[0]) != 4 || afc[0][1] != "magnitude_db" {
+		t.Errorf("filter response has %d rows, header %q", len(afc), afc[0])
+	}
+	if _, err := os.Stat(FileWithTime + "_FIR_result_short.csv"); !os.IsNotExist(err) {
+		t.Errorf("short file was processed: %v", err)
+	}
+}
+
+func TestAverageCommand(t *testing.T) {
+	quietStdio(t)
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("m1/x.csv", "a;b;c\nV;s;A\n1,5;2;не число\n3;не число;не число\n")
+	write("m1/y.csv", "a;b;c\nV;s;A\n2,5;4;не число\n5;1;не число\n")
+	write("m1/notes.txt", "не CSV: не влияет на среднее")
+	write("group/m2/z.csv", "t\nс\n7\n")
+
+	out := filepath.Join(root, "out")
+	if code := run([]string{"average", "-out", out, root}, os.Stderr); code != exitOK {
+		t.Fatalf("average exit code %d", code)
+	}
+	got, err := os.ReadFile(filepath.Join(out, "m1.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a;b;c\nV;s;A\n2;3;не число\n4;1;не число\n"
+	if string(got) != want {
+		t.Errorf("m1.csv:\n%s\nwant:\n%s", got, want)
+	}
+	if got, err := os.ReadFile(filepath.Join(out, "m2.csv")); err != nil || !strings.HasSuffix(string(got), "7\n") {
+		t.Errorf("m2.csv = %q, %v", got, err)
+	}
+
+	write("bad/h1.csv", "a\nV\n1\n")
+	write("bad/h2.csv", "b\nV\n1\n")
+	if code := run([]string{"average", root}, os.Stderr); code != exitFailure {
+		t.Errorf("mismatched headers: exit code %d, want %d", code, exitFailure)
+	}
+}
+
+func TestInfoCommand(t *testing.T) {
+	quietStdio(t)
+	w := memory.DefaultWindow()
+	w.HeaderBytes = 4
+	w.PingPong = true
+	fake, err := memory.NewFakeDevMem(t.TempDir(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	fake.WriteFrame(make([]uint16, w.FrameSize))
+
+	var out bytes.Buffer
+	code := info([]string{"-path", fake.Path(), "-ping-pong", "-header-bytes", "4", "-pulser", "sim"}, &out)
+	if code != exitOK {
+		t.Fatalf("info exit code %d", code)
+	}
+	text := out.String()
+	for _, want := range []string{
+		fmt.Sprintf("буфер 1  0x%08x", w.BaseAddress+int64(w.BufferOffset(1))),
+		"буфер 1: заполнен, ждёт чтения, счётчик 1",
+		"буфер 0: у FPGA, счётчик 0",
+		"генератор: 40000 Гц",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("info output lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestInfoUnmappedRegion(t *testing.T) {
+	quietStdio(t)
+	var out bytes.Buffer
+	code := info([]string{"-sopcinfo", "../fpga_hpsmem/hps_system.sopcinfo", "-region", "onchip_memory2_0.s1"}, &out)
+	if code != exitOK {
+		t.Fatalf("info exit code %d", code)
+	}
+	text := out.String()
+	for _, want := range []string{"0xff200000", "не подключён", "← окно захвата", "адрес окна взят из -base"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("info output lacks %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestFramePeriod(t *testing.T) {
+	if got := framePeriod(1024, 1e5); got != 10240*time.Microsecond {
+		t.Errorf("framePeriod(1024, 1e5) = %v, want 10.24ms", got)
+	}
+	if got := framePeriod(1024, 0); got != 0 {
+		t.Errorf("framePeriod without a rate = %v, want 0", got)
+	}
+}
+
+func TestProcessingParamsCheckBand(t *testing.T) {
+	settings := DefaultSettings()
+	settings.SampleRateHz = 1e6
+	for _, tc := range []struct {
+		low, high, center float64
+		wantHigh          float64
+		ok                bool
+	}{
+		{30e3, 50e3, 40e3, 50e3, true},
+		{0, 0, 0, 0, true},                 // частота заполнения неизвестна
+		{400e3, 600e3, 450e3, 500e3, true}, // верхняя граница — частота Найквиста
+		{50e3, 70e3, 40e3, 70e3, false},
+		{500e3, 700e3, 600e3, 0, false},
+	} {
+		p := processingParams{Settings: settings, LowHz: tc.low, HighHz: tc.high, CenterHz: tc.center}
+		err := p.checkBand()
+		if (err == nil) != tc.ok || (tc.ok && p.HighHz != tc.wantHigh) {
+			t.Errorf("checkBand(%g–%g Hz, %g Hz) = %v, high %g", tc.low, tc.high, tc.center, err, p.HighHz)
+		}
+	}
+}
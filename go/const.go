@@ -1,6 +0,0 @@
-//go:build linux
-// +build linux
-
-package main
-
-var Path = "/dev/mem"
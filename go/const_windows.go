@@ -1,6 +0,0 @@
-//go:build windows
-// +build windows
-
-package main
-
-var Path = "B:\\"
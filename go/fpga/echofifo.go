@@ -0,0 +1,105 @@
+package fpga
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Регистры ведомого Avalon-MM из fpga.v.
+const (
+	// RegEchoData — чтение извлекает слово из fifo_memory, значение в младших 16 битах.
+	RegEchoData Register = 0
+	// RegEchoStatus — [4:0] число слов в FIFO (0..16), бит 16 — признак чтения пустого FIFO.
+	// Запись единицы в бит 16 сбрасывает признак.
+	RegEchoStatus Register = 1
+)
+
+const (
+	echoLevelMask     = 0x1F
+	echoUnderflowFlag = 1 << 16
+)
+
+// EchoRegisterCount — число регистров ведомого (2-битный адрес).
+const EchoRegisterCount = 4
+
+// ErrUnderflow возвращается, когда в FIFO меньше слов, чем запрошено,
+// или прошивка зафиксировала чтение пустого FIFO.
+var ErrUnderflow = errors.New("echo fifo underflow")
+
+// EchoStatus — содержимое регистра состояния FIFO.
+type EchoStatus struct {
+	Level     int  // число слов, готовых к чтению
+	Underflow bool // было чтение пустого FIFO
+}
+
+// EchoFIFO — драйвер FIFO эхо-сигналов из fpga.v.
+type EchoFIFO struct {
+	regs Registers
+}
+
+// NewEchoFIFO создаёт драйвер поверх регистров ведомого.
+func NewEchoFIFO(regs Registers) *EchoFIFO {
+	return &EchoFIFO{regs: regs}
+}
+
+// Status читает регистр состояния.
+func (f *EchoFIFO) Status() (EchoStatus, error) {
+	raw, err := f.regs.Read(RegEchoStatus)
+	if err != nil {
+		return EchoStatus{}, fmt.Errorf("read echo status failed: %w", err)
+	}
+	return EchoStatus{
+		Level:     int(raw & echoLevelMask),
+		Underflow: raw&echoUnderflowFlag != 0,
+	}, nil
+}
+
+// ClearUnderflow сбрасывает признак чтения пустого FIFO.
+func (f *EchoFIFO) ClearUnderflow() error {
+	if err := f.regs.Write(RegEchoStatus, echoUnderflowFlag); err != nil {
+		return fmt.Errorf("clear echo underflow failed: %w", err)
+	}
+	return nil
+}
+
+// Pop извлекает одно слово. Пустой FIFO не читается, возвращается ErrUnderflow.
+func (f *EchoFIFO) Pop() (uint16, error) {
+	var word [1]uint16
+	if _, err := f.PopInto(word[:]); err != nil {
+		return 0, err
+	}
+	return word[0], nil
+}
+
+// PopInto извлекает до len(dst) слов и возвращает их число.
+//
+// Читается не больше слов, чем сообщает регистр состояния, поэтому
+// драйвер сам не вызывает опустошение FIFO. Если слов не хватило или
+// прошивка выставила признак опустошения, возвращается ErrUnderflow
+// вместе с числом успешно прочитанных слов.
+func (f *EchoFIFO) PopInto(dst []uint16) (int, error) {
+	status, err := f.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	n := min(status.Level, len(dst))
+	for i := 0; i < n; i++ {
+		raw, err := f.regs.Read(RegEchoData)
+		if err != nil {
+			return i, fmt.Errorf("read echo data failed: %w", err)
+		}
+		dst[i] = uint16(raw)
+	}
+
+	if status.Underflow {
+		if err := f.ClearUnderflow(); err != nil {
+			return n, err
+		}
+		return n, fmt.Errorf("%w: flagged by hardware", ErrUnderflow)
+	}
+	if n < len(dst) {
+		return n, fmt.Errorf("%w: requested %d words, available %d", ErrUnderflow, len(dst), n)
+	}
+	return n, nil
+}
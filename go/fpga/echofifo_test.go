@@ -0,0 +1,96 @@
+package fpga
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeEchoFIFO моделирует fifo_memory за регистрами fpga.v.
+func fakeEchoFIFO(words ...uint16) *FakeRegisters {
+	regs := NewFakeRegisters()
+	queue := append([]uint16(nil), words...)
+	underflow := false
+
+	regs.OnRead(RegEchoData, func(uint32) uint32 {
+		if len(queue) == 0 {
+			underflow = true
+			return 0xFF
+		}
+		word := queue[0]
+		queue = queue[1:]
+		return 0xABCD0000 | uint32(word) // старшая половина должна отбрасываться
+	})
+	regs.OnRead(RegEchoStatus, func(uint32) uint32 {
+		status := 0xFFE0 | uint32(len(queue)) // биты [15:5] не входят в уровень
+		if underflow {
+			status |= echoUnderflowFlag
+		}
+		return status
+	})
+	regs.OnWrite(RegEchoStatus, func(val uint32) uint32 {
+		if val&echoUnderflowFlag != 0 {
+			underflow = false
+		}
+		return 0
+	})
+	return regs
+}
+
+func TestEchoFIFOPopInto(t *testing.T) {
+	regs := fakeEchoFIFO(1, 2, 3)
+	fifo := NewEchoFIFO(regs)
+
+	dst := make([]uint16, 3)
+	n, err := fifo.PopInto(dst)
+	if err != nil {
+		t.Fatalf("PopInto: %v", err)
+	}
+	if n != 3 || dst[0] != 1 || dst[1] != 2 || dst[2] != 3 {
+		t.Fatalf("PopInto = %d %v, want 3 [1 2 3]", n, dst)
+	}
+}
+
+func TestEchoFIFOUnderflow(t *testing.T) {
+	regs := fakeEchoFIFO(7, 8)
+	fifo := NewEchoFIFO(regs)
+
+	dst := make([]uint16, 4)
+	n, err := fifo.PopInto(dst)
+	if !errors.Is(err, ErrUnderflow) {
+		t.Fatalf("PopInto error = %v, want ErrUnderflow", err)
+	}
+	if n != 2 || dst[0] != 7 || dst[1] != 8 {
+		t.Fatalf("PopInto = %d %v, want 2 [7 8 ...]", n, dst)
+	}
+	if reads := regs.Reads(RegEchoData); reads != 2 {
+		t.Fatalf("driver popped %d words from a FIFO holding 2", reads)
+	}
+
+	if _, err := fifo.Pop(); !errors.Is(err, ErrUnderflow) {
+		t.Fatalf("Pop on empty FIFO = %v, want ErrUnderflow", err)
+	}
+}
+
+func TestEchoFIFOHardwareUnderflow(t *testing.T) {
+	regs := fakeEchoFIFO()
+	// Чтение в обход драйвера опустошает FIFO и выставляет признак.
+	if _, err := regs.Read(RegEchoData); err != nil {
+		t.Fatal(err)
+	}
+
+	fifo := NewEchoFIFO(regs)
+	status, err := fifo.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Underflow {
+		t.Fatal("status does not report the hardware underflow")
+	}
+
+	if _, err := fifo.PopInto(nil); !errors.Is(err, ErrUnderflow) {
+		t.Fatalf("PopInto error = %v, want ErrUnderflow", err)
+	}
+	if status, _ := fifo.Status(); status.Underflow {
+		t.Fatal("underflow flag was not cleared")
+	}
+}
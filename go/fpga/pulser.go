@@ -0,0 +1,201 @@
+package fpga
+
+import (
+	"fmt"
+	"math"
+)
+
+// Регистры генератора зондирующих импульсов в fpga.v.
+const (
+	// RegPulseControl — [0] разрешение, [15:8] периодов в пачке (0 — непрерывный меандр),
+	// [31:16] полупериод заполнения в тактах.
+	RegPulseControl Register = 2
+	// RegPulsePeriod — период повторения пачек в тактах (0 — пачки идут подряд).
+	RegPulsePeriod Register = 3
+)
+
+const (
+	// ClockHz — тактовая частота логики FPGA.
+	ClockHz = 50e6
+	// DefaultBurstFreqHz — частота заполнения после сброса (PULSE_PERIOD = 1250 тактов).
+	DefaultBurstFreqHz = 40e3
+
+	pulseArmFlag    = 1 << 0
+	pulseCyclesMask = 0xFF
+	pulseCyclesPos  = 8
+	pulseHalfPos    = 16
+	pulseHalfMax    = 0xFFFF
+)
+
+// PulserSettings — параметры возбуждения преобразователя.
+type PulserSettings struct {
+	BurstFreqHz float64 // частота заполнения пачки, Гц
+	Cycles      int     // периодов в пачке, 0 — непрерывный меандр
+	PRFHz       float64 // частота повторения пачек, Гц; 0 — без пауз
+	Armed       bool    // генерация разрешена
+}
+
+// DefaultPulserSettings возвращает состояние генератора после сброса:
+// непрерывный меандр 40 кГц.
+func DefaultPulserSettings() PulserSettings {
+	return PulserSettings{BurstFreqHz: DefaultBurstFreqHz, Armed: true}
+}
+
+// Band возвращает полосу главного лепестка спектра пачки, на которую
+// настраивается обработка: f ± f/N для пачки из N периодов.
+// Для непрерывного меандра берётся ±10 % от частоты заполнения.
+func (s PulserSettings) Band() (lowHz, highHz float64) {
+	half := s.BurstFreqHz / 10
+	if s.Cycles > 0 {
+		half = s.BurstFreqHz / float64(s.Cycles)
+	}
+	return math.Max(s.BurstFreqHz-half, 0), s.BurstFreqHz + half
+}
+
+// Pulser управляет генератором ultrasonic_generator через регистры fpga.v.
+type Pulser struct {
+	regs    Registers
+	clockHz float64
+}
+
+// NewPulser создаёт драйвер генератора поверх регистров ведомого.
+func NewPulser(regs Registers) *Pulser {
+	return &Pulser{regs: regs, clockHz: ClockHz}
+}
+
+// Settings читает действующие параметры. Частоты пересчитываются из
+// тактов, поэтому учитывают округление при записи.
+func (p *Pulser) Settings() (PulserSettings, error) {
+	control, err := p.regs.Read(RegPulseControl)
+	if err != nil {
+		return PulserSettings{}, fmt.Errorf("read pulser control failed: %w", err)
+	}
+	period, err := p.regs.Read(RegPulsePeriod)
+	if err != nil {
+		return PulserSettings{}, fmt.Errorf("read pulser period failed: %w", err)
+	}
+
+	settings := PulserSettings{
+		Cycles: int(control >> pulseCyclesPos & pulseCyclesMask),
+		Armed:  control&pulseArmFlag != 0,
+	}
+	if half := control >> pulseHalfPos; half != 0 {
+		settings.BurstFreqHz = p.clockHz / float64(2*half)
+	}
+	if period != 0 {
+		settings.PRFHz = p.clockHz / float64(period)
+	}
+	return settings, nil
+}
+
+// Configure записывает параметры и возвращает фактически установленные.
+// На время записи генератор выключается, чтобы не выдать пачку со смешанными параметрами.
+func (p *Pulser) Configure(s PulserSettings) (PulserSettings, error) {
+	control, period, err := p.encode(s)
+	if err != nil {
+		return PulserSettings{}, err
+	}
+	if err := p.regs.Write(RegPulseControl, control&^pulseArmFlag); err != nil {
+		return PulserSettings{}, fmt.Errorf("write pulser control failed: %w", err)
+	}
+	if err := p.regs.Write(RegPulsePeriod, period); err != nil {
+		return PulserSettings{}, fmt.Errorf("write pulser period failed: %w", err)
+	}
+	if s.Armed {
+		if err := p.regs.Write(RegPulseControl, control); err != nil {
+			return PulserSettings{}, fmt.Errorf("write pulser control failed: %w", err)
+		}
+	}
+	return p.Settings()
+}
+
+// Arm разрешает генерацию с текущими параметрами.
+func (p *Pulser) Arm() error {
+	return p.setArmed(true)
+}
+
+// Disarm останавливает генерацию; выход генератора уходит в ноль.
+func (p *Pulser) Disarm() error {
+	return p.setArmed(false)
+}
+
+func (p *Pulser) setArmed(armed bool) error {
+	control, err := p.regs.Read(RegPulseControl)
+	if err != nil {
+		return fmt.Errorf("read pulser control failed: %w", err)
+	}
+	if armed {
+		control |= pulseArmFlag
+	} else {
+		control &^= pulseArmFlag
+	}
+	if err := p.regs.Write(RegPulseControl, control); err != nil {
+		return fmt.Errorf("write pulser control failed: %w", err)
+	}
+	return nil
+}
+
+// encode переводит параметры в значения регистров.
+func (p *Pulser) encode(s PulserSettings) (control, period uint32, err error) {
+	if s.BurstFreqHz <= 0 {
+		return 0, 0, fmt.Errorf("invalid burst frequency %g Hz", s.BurstFreqHz)
+	}
+	half := math.Round(p.clockHz / (2 * s.BurstFreqHz))
+	if half < 1 || half > pulseHalfMax {
+		return 0, 0, fmt.Errorf("burst frequency %g Hz is outside [%g, %g] Hz",
+			s.BurstFreqHz, p.clockHz/(2*pulseHalfMax), p.clockHz/2)
+	}
+	if s.Cycles < 0 || s.Cycles > pulseCyclesMask {
+		return 0, 0, fmt.Errorf("invalid burst cycles %d, want 0..%d", s.Cycles, pulseCyclesMask)
+	}
+
+	if s.PRFHz < 0 {
+		return 0, 0, fmt.Errorf("invalid pulse repetition frequency %g Hz", s.PRFHz)
+	}
+	if s.PRFHz > 0 {
+		if s.Cycles == 0 {
+			return 0, 0, fmt.Errorf("pulse repetition frequency requires a finite burst")
+		}
+		clocks := math.Round(p.clockHz / s.PRFHz)
+		burst := 2 * half * float64(s.Cycles)
+		if clocks <= burst || clocks > math.MaxUint32 {
+			return 0, 0, fmt.Errorf("pulse repetition frequency %g Hz does not fit a %d-cycle burst at %g Hz",
+				s.PRFHz, s.Cycles, s.BurstFreqHz)
+		}
+		period = uint32(clocks)
+	}
+
+	control = uint32(half)<<pulseHalfPos | uint32(s.Cycles)<<pulseCyclesPos
+	if s.Armed {
+		control |= pulseArmFlag
+	}
+	return control, period, nil
+}
+
+// NewSimulatedRegisters возвращает программную модель регистров fpga.v
+// в состоянии после сброса: FIFO эха пуст, генератор выдаёт меандр 40 кГц.
+// Записи в регистр управления генератором проходят через ту же маску
+// и ограничения, что и в прошивке.
+func NewSimulatedRegisters() *FakeRegisters {
+	regs := NewFakeRegisters()
+	regs.Set(RegPulseControl, uint32(math.Round(ClockHz/(2*DefaultBurstFreqHz)))<<pulseHalfPos|pulseArmFlag)
+	regs.OnRead(RegEchoData, func(uint32) uint32 {
+		regs.Set(RegEchoStatus, regs.Get(RegEchoStatus)|echoUnderflowFlag)
+		return 0
+	})
+	regs.OnWrite(RegEchoStatus, func(val uint32) uint32 {
+		status := regs.Get(RegEchoStatus)
+		if val&echoUnderflowFlag != 0 {
+			status &^= echoUnderflowFlag
+		}
+		return status
+	})
+	regs.OnWrite(RegPulseControl, func(val uint32) uint32 {
+		val &= pulseHalfMax<<pulseHalfPos | pulseCyclesMask<<pulseCyclesPos | pulseArmFlag
+		if val>>pulseHalfPos == 0 {
+			val |= 1 << pulseHalfPos
+		}
+		return val
+	})
+	return regs
+}
@@ -0,0 +1,85 @@
+package fpga
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPulserResetState(t *testing.T) {
+	settings, err := NewPulser(NewSimulatedRegisters()).Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings != DefaultPulserSettings() {
+		t.Fatalf("reset settings = %+v, want %+v", settings, DefaultPulserSettings())
+	}
+}
+
+func TestPulserConfigure(t *testing.T) {
+	regs := NewSimulatedRegisters()
+	pulser := NewPulser(regs)
+
+	got, err := pulser.Configure(PulserSettings{BurstFreqHz: 2.25e6, Cycles: 3, PRFHz: 1e3, Armed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 50 МГц / (2·2,25 МГц) = 11,1 → 11 тактов полупериода.
+	if want := ClockHz / 22; math.Abs(got.BurstFreqHz-want) > 1e-6 {
+		t.Fatalf("burst frequency = %g, want %g", got.BurstFreqHz, want)
+	}
+	if got.Cycles != 3 || got.PRFHz != 1e3 || !got.Armed {
+		t.Fatalf("settings = %+v", got)
+	}
+	if control := regs.Get(RegPulseControl); control != 11<<16|3<<8|1 {
+		t.Fatalf("control register = %#x", control)
+	}
+	if period := regs.Get(RegPulsePeriod); period != 50000 {
+		t.Fatalf("period register = %d, want 50000", period)
+	}
+	// Генератор выключается на время перенастройки.
+	if writes := regs.Writes(RegPulseControl); writes != 2 {
+		t.Fatalf("control written %d times, want disarm + arm", writes)
+	}
+}
+
+func TestPulserArmDisarm(t *testing.T) {
+	regs := NewSimulatedRegisters()
+	pulser := NewPulser(regs)
+
+	if err := pulser.Disarm(); err != nil {
+		t.Fatal(err)
+	}
+	settings, _ := pulser.Settings()
+	if settings.Armed || settings.BurstFreqHz != DefaultBurstFreqHz {
+		t.Fatalf("after Disarm: %+v", settings)
+	}
+	if err := pulser.Arm(); err != nil {
+		t.Fatal(err)
+	}
+	if settings, _ := pulser.Settings(); !settings.Armed {
+		t.Fatal("Arm did not set the enable bit")
+	}
+}
+
+func TestPulserRejectsInvalidSettings(t *testing.T) {
+	pulser := NewPulser(NewSimulatedRegisters())
+	for _, s := range []PulserSettings{
+		{BurstFreqHz: 0},
+		{BurstFreqHz: 100e6},                       // полупериод меньше такта
+		{BurstFreqHz: 100},                         // полупериод не помещается в 16 бит
+		{BurstFreqHz: 1e6, Cycles: 256},            // число периодов не помещается в 8 бит
+		{BurstFreqHz: 1e6, PRFHz: 100},             // PRF у непрерывного меандра
+		{BurstFreqHz: 1e6, Cycles: 10, PRFHz: 2e5}, // пачка длиннее периода повторения
+	} {
+		if _, err := pulser.Configure(s); err == nil {
+			t.Errorf("Configure(%+v) succeeded", s)
+		}
+	}
+}
+
+func TestPulserBand(t *testing.T) {
+	low, high := PulserSettings{BurstFreqHz: 1e6, Cycles: 4}.Band()
+	if low != 0.75e6 || high != 1.25e6 {
+		t.Fatalf("band = [%g, %g], want [0.75e6, 1.25e6]", low, high)
+	}
+}
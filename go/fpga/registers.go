@@ -0,0 +1,186 @@
+package fpga
+
+import (
+	"fmt"
+	"sync"
+
+	"fpga-ultrasound-go/memory"
+)
+
+const (
+	// LightweightBridgeBase — адрес моста HPS-to-FPGA lightweight (hps_0_bridges.axi_h2f_lw).
+	LightweightBridgeBase = 0xFF200000
+	// LightweightBridgeSpan — размер окна моста lightweight.
+	LightweightBridgeSpan = 0x200000
+	// WordSize — ширина регистра Avalon-MM в байтах.
+	WordSize = 4
+)
+
+// Register — номер 32-битного регистра ведомого Avalon-MM (адрес в словах).
+type Register uint32
+
+// Offset возвращает байтовое смещение регистра от начала ведомого.
+func (r Register) Offset() int {
+	return int(r) * WordSize
+}
+
+// Registers — доступ к регистрам ведомого Avalon-MM.
+type Registers interface {
+	Read(reg Register) (uint32, error)
+	Write(reg Register, val uint32) error
+}
+
+// MappedRegisters — регистры ведомого, отображённые через /dev/mem.
+type MappedRegisters struct {
+	device *memory.Device
+	count  int
+}
+
+// OpenRegisters отображает count регистров ведомого, расположенного по
+// смещению offset внутри моста lightweight.
+func OpenRegisters(path string, offset int64, count int) (*MappedRegisters, error) {
+	return openRegisters(path, offset, count, memory.OpenDeviceRW)
+}
+
+// OpenRegistersReadOnly отображает регистры только для чтения: Write
+// возвращает ошибку. Подходит для диагностики, которая не должна менять
+// состояние прошивки.
+func OpenRegistersReadOnly(path string, offset int64, count int) (*MappedRegisters, error) {
+	return openRegisters(path, offset, count, memory.OpenDevice)
+}
+
+func openRegisters(path string, offset int64, count int, open func(string, int64, int) (*memory.Device, error)) (*MappedRegisters, error) {
+	if path == "" {
+		path = "/dev/mem"
+	}
+	if offset < 0 || offset+int64(count*WordSize) > LightweightBridgeSpan {
+		return nil, fmt.Errorf("registers %#x+%d are outside the lightweight bridge", offset, count*WordSize)
+	}
+	device, err := open(path, LightweightBridgeBase+offset, count*WordSize)
+	if err != nil {
+		return nil, err
+	}
+	return &MappedRegisters{device: device, count: count}, nil
+}
+
+func (m *MappedRegisters) Read(reg Register) (uint32, error) {
+	if err := m.check(reg); err != nil {
+		return 0, err
+	}
+	return m.device.Load32(reg.Offset())
+}
+
+func (m *MappedRegisters) Write(reg Register, val uint32) error {
+	if err := m.check(reg); err != nil {
+		return err
+	}
+	return m.device.Store32(reg.Offset(), val)
+}
+
+// Close снимает отображение регистров.
+func (m *MappedRegisters) Close() error {
+	return m.device.Close()
+}
+
+func (m *MappedRegisters) check(reg Register) error {
+	if int(reg) >= m.count {
+		return fmt.Errorf("register %d is outside the %d mapped registers", reg, m.count)
+	}
+	return nil
+}
+
+// FakeRegisters — программный регистровый файл для тестов и симуляции.
+//
+// Без обработчиков регистры ведут себя как обычная память. Обработчики
+// OnRead и OnWrite позволяют моделировать побочные эффекты прошивки,
+// например извлечение слова из FIFO при чтении.
+type FakeRegisters struct {
+	mu      sync.Mutex
+	values  map[Register]uint32
+	onRead  map[Register]func(uint32) uint32
+	onWrite map[Register]func(uint32) uint32
+	reads   map[Register]int
+	writes  map[Register]int
+}
+
+// NewFakeRegisters создаёт пустой регистровый файл.
+func NewFakeRegisters() *FakeRegisters {
+	return &FakeRegisters{
+		values:  make(map[Register]uint32),
+		onRead:  make(map[Register]func(uint32) uint32),
+		onWrite: make(map[Register]func(uint32) uint32),
+		reads:   make(map[Register]int),
+		writes:  make(map[Register]int),
+	}
+}
+
+// Set записывает значение регистра в обход обработчиков.
+func (f *FakeRegisters) Set(reg Register, val uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[reg] = val
+}
+
+// Get читает значение регистра в обход обработчиков.
+func (f *FakeRegisters) Get(reg Register) uint32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[reg]
+}
+
+// OnRead задаёт обработчик чтения: он получает текущее значение
+// и возвращает то, что увидит драйвер.
+func (f *FakeRegisters) OnRead(reg Register, fn func(current uint32) uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onRead[reg] = fn
+}
+
+// OnWrite задаёт обработчик записи: он получает записываемое значение
+// и возвращает то, что будет сохранено в регистре.
+func (f *FakeRegisters) OnWrite(reg Register, fn func(val uint32) uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onWrite[reg] = fn
+}
+
+// Reads возвращает число обращений на чтение к регистру.
+func (f *FakeRegisters) Reads(reg Register) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reads[reg]
+}
+
+// Writes возвращает число обращений на запись к регистру.
+func (f *FakeRegisters) Writes(reg Register) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes[reg]
+}
+
+func (f *FakeRegisters) Read(reg Register) (uint32, error) {
+	f.mu.Lock()
+	fn := f.onRead[reg]
+	val := f.values[reg]
+	f.reads[reg]++
+	f.mu.Unlock()
+
+	// Обработчик вызывается без блокировки, чтобы он мог обращаться к Set и Get.
+	if fn != nil {
+		val = fn(val)
+	}
+	return val, nil
+}
+
+func (f *FakeRegisters) Write(reg Register, val uint32) error {
+	f.mu.Lock()
+	fn := f.onWrite[reg]
+	f.writes[reg]++
+	f.mu.Unlock()
+
+	if fn != nil {
+		val = fn(val)
+	}
+	f.Set(reg, val)
+	return nil
+}
@@ -0,0 +1,61 @@
+package fpga
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeBridge создаёт разреженный файл с раскладкой /dev/mem до конца
+// моста lightweight; в регистр reg ведомого по смещению offset записано val.
+func fakeBridge(t *testing.T, offset int64, reg Register, val uint32) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mem")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := file.Truncate(LightweightBridgeBase + LightweightBridgeSpan); err != nil {
+		t.Fatal(err)
+	}
+	var word [WordSize]byte
+	binary.LittleEndian.PutUint32(word[:], val)
+	if _, err := file.WriteAt(word[:], LightweightBridgeBase+offset+int64(reg.Offset())); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenRegistersReadOnly(t *testing.T) {
+	const offset = 0x100
+	path := fakeBridge(t, offset, 2, 0xdeadbeef)
+
+	regs, err := OpenRegistersReadOnly(path, offset, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer regs.Close()
+	if v, err := regs.Read(2); err != nil || v != 0xdeadbeef {
+		t.Fatalf("Read(2) = %#x, %v; want 0xdeadbeef", v, err)
+	}
+	if err := regs.Write(2, 1); err == nil {
+		t.Fatal("Write through a read-only mapping succeeded")
+	}
+	if _, err := regs.Read(4); err == nil {
+		t.Fatal("Read past the mapped registers succeeded")
+	}
+
+	rw, err := OpenRegisters(path, offset, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rw.Close()
+	if err := rw.Write(2, 7); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := regs.Read(2); v != 7 {
+		t.Fatalf("read-only view sees %#x after a write, want 7", v)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"fpga-ultrasound-go/fpga"
+	"fpga-ultrasound-go/memory"
+	"fpga-ultrasound-go/sopc"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// runInfo — команда info: раскладка окна захвата, регионы из .sopcinfo
+// и, если заданы устройство или регистры, их текущее содержимое.
+// Команда ничего не записывает в устройство.
+func runInfo(args []string) int {
+	return info(args, os.Stdout)
+}
+
+func info(args []string, out io.Writer) int {
+	fs := newFlagSet("info", "")
+	wf := addWindowFlags(fs)
+	regs := addRegisterFlags(fs, "прочитать регистры fpga.v: /dev/mem или sim (программная модель)")
+	path := fs.String("path", "", "прочитать служебные слова окна из устройства (/dev/mem); пусто — только раскладка")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		return usageError(fs, "unexpected arguments: %q", fs.Args())
+	}
+
+	w := wf.window()
+	if wf.sopcinfo != "" {
+		sys, err := sopc.Load(wf.sopcinfo)
+		if err != nil {
+			return fail("Sopcinfo error: %v", err)
+		}
+		writeRegions(out, sys, wf.region)
+		if wf.region != "" {
+			region, err := memory.WindowFromRegion(sys, wf.region, w)
+			switch {
+			case errors.Is(err, sopc.ErrUnmapped):
+				// Раскладку всё равно показываем: адрес окна — из -base.
+				fmt.Fprintf(out, "Интерфейс %s не подключён ни к одному ведущему, адрес окна взят из -base\n\n", wf.region)
+			case err != nil:
+				return fail("Region error: %v", err)
+			default:
+				w = region
+			}
+		}
+	} else if wf.region != "" {
+		return usageError(fs, "region %q requires -sopcinfo", wf.region)
+	}
+	if err := w.Validate(); err != nil {
+		return fail("Window error: %v", err)
+	}
+	writeWindow(out, w)
+
+	if *path != "" {
+		if err := writeDevice(out, *path, w); err != nil {
+			return fail("Device error: %v", err)
+		}
+	}
+	if regs.dev != "" {
+		if err := writeRegisters(out, regs); err != nil {
+			return fail("Registers error: %v", err)
+		}
+	}
+	return exitOK
+}
+
+// writeRegions выводит адресные окна ведомых интерфейсов; окно захвата отмечается.
+// У интерфейсов без подключения к ведущему вместо адресов стоят прочерки.
+func writeRegions(out io.Writer, sys *sopc.System, capture string) {
+	fmt.Fprintf(out, "Система %s\n", sys.Name)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  интерфейс\tтип\tначало\tконец\tразмер\tведущий\t")
+	for _, r := range sys.Regions() {
+		mark := ""
+		if r.Name == capture {
+			mark = "← окно захвата"
+		}
+		if !r.Mapped {
+			fmt.Fprintf(tw, "  %s\t%s\t—\t—\t%d\tне подключён\t%s\n", r.Name, r.Kind, r.Span, mark)
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t0x%08x\t0x%08x\t%d\t%s\t%s\n", r.Name, r.Kind, r.Base, r.End(), r.Span, r.Master, mark)
+	}
+	tw.Flush()
+	fmt.Fprintln(out)
+}
+
+// writeWindow выводит раскладку окна захвата.
+func writeWindow(out io.Writer, w memory.Window) {
+	base := uint64(w.BaseAddress)
+	fmt.Fprintln(out, "Окно захвата")
+	fmt.Fprintf(out, "  адрес:     0x%08x–0x%08x (%d байт)\n", base, base+uint64(w.MapLen()), w.MapLen())
+	fmt.Fprintf(out, "  кадр:      %d отсчётов %s, полная шкала %g В, %d байт\n",
+		w.FrameSize, w.Encoding, w.FullScaleVolts, w.ByteLen())
+	if w.HeaderBytes > 0 {
+		fmt.Fprintf(out, "  заголовок: %d байт, первое слово — счётчик кадров\n", w.HeaderBytes)
+	}
+	if !w.PingPong {
+		return
+	}
+	fmt.Fprintf(out, "  ping-pong: управление 0x%08x (статус +0, подтверждение +4, переполнения +8)\n", base)
+	for i := range 2 {
+		fmt.Fprintf(out, "             буфер %d  0x%08x\n", i, base+uint64(w.BufferOffset(i)))
+	}
+}
+
+// writeDevice читает служебные слова окна только для чтения.
+func writeDevice(out io.Writer, path string, w memory.Window) error {
+	device, err := memory.OpenDevice(path, w.BaseAddress, w.MapLen())
+	if err != nil {
+		return err
+	}
+	defer device.Close()
+
+	fmt.Fprintf(out, "Устройство %s\n", path)
+	if w.PingPong {
+		c, err := memory.ReadPingPongControl(device)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "  статус %#x, подтверждение %#x, переполнений %d, последний буфер %d\n",
+			c.Status, c.Ack, c.Overruns, c.Newest())
+		for i := range 2 {
+			state := "у FPGA"
+			if c.Owned(i) {
+				state = "заполнен, ждёт чтения"
+			}
+			fmt.Fprintf(out, "  буфер %d: %s", i, state)
+			if w.HeaderBytes >= 4 {
+				counter, err := device.Load32(w.BufferOffset(i))
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, ", счётчик %d", counter)
+			}
+			fmt.Fprintln(out)
+		}
+		return nil
+	}
+	if w.HeaderBytes >= 4 {
+		counter, err := device.Load32(0)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "  счётчик кадров %d\n", counter)
+	}
+	return nil
+}
+
+// writeRegisters выводит состояние FIFO эха и генератора. Регистры
+// отображаются только для чтения; регистр данных FIFO не читается:
+// чтение извлекает слово.
+func writeRegisters(out io.Writer, f *registerFlags) error {
+	regs, closeRegs, err := f.open(false)
+	if err != nil {
+		return err
+	}
+	defer closeRegs()
+
+	status, err := fpga.NewEchoFIFO(regs).Status()
+	if err != nil {
+		return err
+	}
+	pulser, err := fpga.NewPulser(regs).Settings()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Регистры fpga.v (%s, смещение %#x)\n", f.dev, f.offset)
+	fmt.Fprintf(out, "  FIFO эха:  слов %d, чтение пустого FIFO: %t\n", status.Level, status.Underflow)
+	fmt.Fprintf(out, "  генератор: %.0f Гц, периодов %d, PRF %.1f Гц, включён: %t\n",
+		pulser.BurstFreqHz, pulser.Cycles, pulser.PRFHz, pulser.Armed)
+	return nil
+}
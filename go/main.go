@@ -1,134 +1,250 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"fpga-ultrasound-go/memory"
 	"fpga-ultrasound-go/storage"
 	"fpga-ultrasound-go/ultrasignal"
+	"io"
 	"log"
 	"math"
 	"os"
-	"runtime"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	FileWithFreq        = "[AFC]"
-	FileWithTime        = "[Time]"
-	FilterWindow        = 5
-	CurrentSampleRateHz = 1e5                      // 0.1 МГц
-	SampleRateHz        = CurrentSampleRateHz * 10 // 10 МГц — типичная частота дискретизации ультразвука
-	FFTKernelSize       = 1000                     // Размер окна для FFT
-	FIRKernelSize       = 101                      // Нечётное число
-	LowCutoffFreq       = 1e-3                     // 0.001 Гц
-	HighCutoffFreq      = 1e6                      // 1 МГц
-	EchoThreshold       = 0.6                      // Порог обнаружения эха
-	Threshold           = 0.5
-	Thickness           = 10.0 // Толщина образца в мм
-	Mode                = "A0" // Модальный режим ("A0" или "S0")
+	FileWithFreq = "[AFC]"
+	FileWithTime = "[Time]"
+	SettingsFile = "run_settings.json" // действующие настройки сохраняются рядом с результатами
+	SummaryFile  = "run_summary.json"  // итоги запуска
 )
 
+// Коды завершения.
+const (
+	exitOK      = 0 // команда выполнена; сбор остановлен сигналом или исчерпан источник
+	exitFailure = 1 // ошибка настройки, устройства или файлов
+	exitUsage   = 2 // неверная команда, флаг или аргумент
+	exitHalted  = 3 // сбор остановлен проверкой кадра (-health ...=halt)
+)
+
+// command — подкоманда программы.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"acquire", "сбор кадров с устройства, обработка и запись сессии", runAcquire},
+	{"replay", "воспроизведение записанной сессии через конвейер обработки", runReplay},
+	{"process", "обработка сохранённых файлов отсчётов (время,значение)", runProcess},
+	{"average", "усреднение CSV-измерений по каталогам", runAverage},
+	{"info", "карта памяти устройства: окно захвата, регионы Qsys, регистры", runInfo},
+}
+
 func main() {
-	logFile, err := logSettings()
-	if err != nil {
-		log.Printf(err.Error())
-	}
-	defer logFile.Close()
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
 
-	go func() {
-		var m runtime.MemStats
-		for {
-			runtime.ReadMemStats(&m)
-			fmt.Printf("Количество горутин: %d\n", runtime.NumGoroutine())
-			fmt.Printf("Текущий объем занятой памяти: %d b\n", bToMb(m.Alloc))
-			fmt.Printf("Всего выделенно памяти во время запуска: %d b\n", bToMb(m.TotalAlloc))
-			fmt.Printf("Объем памяти, полученный от операционной системы: %d b\n", bToMb(m.TotalAlloc))
-			fmt.Printf("Количество cpu: %d\n", runtime.NumCPU())
-		}
-	}()
-	log.Println("🚀 Starting FPGA Ultrasound Data Collector...")
-	var dataBuffer []float64
-	var raw = make(chan []float64)
-
-	//if runtime.GOOS == "windows" {
-	//	Path = memory.CurrentData(CurrentSampleRateHz)
-	//}
-
-	go func(raw chan []float64) {
-		log.Println("Чтение данных")
-		for {
-			var data []float64
-			err := memory.ReadFrame(Path, CurrentSampleRateHz, &data)
-			if err != nil {
-				log.Printf("❌ Memory read error: %v", err)
-				break
-			}
-			raw <- data
-			if err := storage.SaveSample("./"+FileWithTime+"_RAW_result.csv", data); err != nil {
-				log.Printf("❌ raw save error: %v", err)
-			}
+// run выбирает подкоманду по первому аргументу и возвращает код завершения.
+// Флаги без команды относятся к acquire, как до появления подкоманд.
+func run(args []string, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	name := args[0]
+	switch {
+	case name == "help" || name == "-h" || name == "-help" || name == "--help":
+		usage(stderr)
+		return exitOK
+	case strings.HasPrefix(name, "-"):
+		return runAcquire(args)
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args[1:])
 		}
-		return
-	}(raw)
-
-	log.Println("Сбор данных")
-	var once sync.Once
-	for {
-	loop:
-		for {
-			select {
-			case data := <-raw:
-				dataBuffer = append(dataBuffer, data...)
-				log.Printf("Длина полученных данных: %+v", len(dataBuffer))
-				if len(dataBuffer) >= FFTKernelSize {
-					break loop
-				}
-			}
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+	usage(stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Использование: %s <команда> [флаги] [аргументы]\n\nКоманды:\n", programName())
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nФлаги команды: %s <команда> -h\n", programName())
+	fmt.Fprintf(w, "Коды завершения: %d — успех, %d — ошибка, %d — неверный вызов, %d — остановка проверкой кадра\n",
+		exitOK, exitFailure, exitUsage, exitHalted)
+}
+
+func programName() string {
+	if len(os.Args) == 0 {
+		return "fpga-ultrasound"
+	}
+	return os.Args[0]
+}
+
+// newFlagSet создаёт набор флагов подкоманды; args описывает позиционные аргументы.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Использование: %s %s [флаги] %s\n\n", programName(), name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags разбирает флаги подкоманды. Если разбор не удался или запрошена
+// справка, ok == false, а code — код завершения.
+func parseFlags(fs *flag.FlagSet, args []string) (code int, ok bool) {
+	err := fs.Parse(args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK, false
+	case err != nil:
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// isFlagSet сообщает, задан ли флаг name в командной строке явно.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
+	})
+	return set
+}
+
+// usageError сообщает о неверном вызове подкоманды.
+func usageError(fs *flag.FlagSet, format string, args ...any) int {
+	fmt.Fprintf(fs.Output(), format+"\n\n", args...)
+	fs.Usage()
+	return exitUsage
+}
+
+// fail записывает ошибку в журнал и в stderr и возвращает exitFailure.
+func fail(format string, args ...any) int {
+	msg := fmt.Sprintf(format, args...)
+	log.Print("❌ " + msg)
+	fmt.Fprintln(os.Stderr, msg)
+	return exitFailure
+}
+
+// loadRunSettings собирает настройки обработки, выводит их и сохраняет
+// рядом с результатами.
+func loadRunSettings(configFile string, fs *flag.FlagSet, bound *Settings) (Settings, error) {
+	settings, err := LoadSettings(configFile, fs, bound)
+	if err != nil {
+		return settings, err
+	}
+	fmt.Printf("Настройки обработки:\n%s\n", settings)
+	log.Printf("Настройки обработки:\n%s", settings)
+	if err := storage.SaveJSON("./"+SettingsFile, settings); err != nil {
+		log.Printf("❌ settings save error: %v", err)
+	}
+	return settings, nil
+}
 
-		once.Do(func() {
-			go processing(dataBuffer)
-		})
+// processingParams — параметры обработки, зависящие от возбуждения и калибровки.
+type processingParams struct {
+	Settings
+
+	LowHz     float64 // полоса полосового фильтра
+	HighHz    float64
+	CenterHz  float64     // частота заполнения генератора, 0 — неизвестна
+	Unit      memory.Unit // единица отсчётов
+	FullScale float64     // полная шкала АЦП в единицах Unit
+}
+
+// checkBand проверяет, что частота заполнения генератора (если известна)
+// лежит ниже частоты Найквиста и внутри полосы фильтра; верхняя граница
+// полосы при этом ограничивается частотой Найквиста.
+func (p *processingParams) checkBand() error {
+	if p.CenterHz <= 0 {
+		return nil
+	}
+	nyquist := p.SampleRateHz / 2
+	if p.CenterHz >= nyquist {
+		return fmt.Errorf("burst frequency %.0f Hz is not below the Nyquist frequency %.0f Hz", p.CenterHz, nyquist)
 	}
+	p.HighHz = math.Min(p.HighHz, nyquist)
+	if p.CenterHz < p.LowHz || p.CenterHz > p.HighHz {
+		return fmt.Errorf("burst frequency %.0f Hz is outside the filter band %.0f–%.0f Hz", p.CenterHz, p.LowHz, p.HighHz)
+	}
+	return nil
 }
 
-func processing(data []float64) {
+// processing обрабатывает отсчёты одного канала и возвращает время пролёта;
+// suffix добавляется к именам выходных файлов. data не изменяется и не сохраняется.
+// Отменённый ctx пропускает обработку; начатые файлы дописываются целиком.
+func processing(ctx context.Context, data []float64, params processingParams, suffix string) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 	FilePath := "./"
 
-	data = ultrasignal.ThresholdFilter(data, Threshold)
+	// Пороги заданы в долях полной шкалы и переводятся в единицы отсчётов.
+	log.Printf("Обработка%s в единицах %s, полная шкала %g %s", suffix, params.Unit, params.FullScale, params.Unit)
+	data = ultrasignal.ThresholdFilter(data, params.Threshold*params.FullScale)
 
 	log.Println("1️⃣ Сглаживание с использованием скользящего среднего")
-	smoothed := ultrasignal.MovingAverage(data, FilterWindow)
+	smoothed := ultrasignal.MovingAverage(data, params.FilterWindow)
 
 	log.Println("2️⃣ Применение фильтра (полосовой фильтр)")
-	kernel := ultrasignal.FIRBandPassKernel(FIRKernelSize, LowCutoffFreq, HighCutoffFreq, SampleRateHz)
-	filteredSignal := ultrasignal.BandPassFilter(smoothed, kernel)
-	if err := storage.SaveSample(FilePath+FileWithTime+"_FIR_result.csv", filteredSignal); err != nil {
+	kernel := ultrasignal.FIRBandPassKernel(params.FIRKernelSize, params.LowHz, params.HighHz, params.SampleRateHz)
+	filtered, err := ultrasignal.ApplyFIR(smoothed, kernel, ultrasignal.FilterPhase(params.FilterPhase))
+	if err != nil {
+		return 0, err
+	}
+	filteredSignal := filtered.Samples
+	log.Printf("Фильтр %s, задержка %.1f отсчётов", params.FilterPhase, filtered.Delay)
+	if err := storage.SaveSample(FilePath+FileWithTime+"_FIR_result"+suffix+".csv", filteredSignal); err != nil {
 		log.Printf("❌ FIR save error: %v", err)
 	}
 
-	log.Println("3️⃣ Вычисление АЧХ фильтра")
-	freqsAFC, afc := ultrasignal.ComputeAFC(filteredSignal, SampleRateHz)
-	if err := storage.SaveSpectrum(FilePath+FileWithFreq+"_filter_frequency_response.csv", freqsAFC, afc); err != nil {
+	log.Println("3️⃣ Вычисление частотной характеристики фильтра")
+	// Характеристика ядра с учётом способа применения, а не спектр сигнала.
+	response, err := filterResponse(kernel, params)
+	if err != nil {
+		return 0, err
+	}
+	if err := storage.SaveFrequencyResponse(FilePath+FileWithFreq+"_filter_frequency_response"+suffix+".csv",
+		response.Freqs, response.MagnitudeDB, response.Phase, response.GroupDelay); err != nil {
 		log.Printf("❌ AFC save error: %v", err)
 	}
 
 	log.Println("4️⃣ Расчёт огибающей через Гильберта")
 	envelopeHilbert := ultrasignal.ComputeEnvelopeHilbert(filteredSignal)
-	if err := storage.SaveSample(FilePath+FileWithTime+"_Envelope_via_Hilbert.csv", envelopeHilbert); err != nil {
+	if err := storage.SaveSample(FilePath+FileWithTime+"_Envelope_via_Hilbert"+suffix+".csv", envelopeHilbert); err != nil {
 		log.Printf("❌ Hilbert envelope save error: %v", err)
 	}
 
 	log.Println("5️⃣ Обнаружение эхо-сигналов и расчет времени полета")
-	echoIndices := ultrasignal.DetectEchoes(envelopeHilbert, EchoThreshold)
-	tof := ultrasignal.GetTimeOfFlight(echoIndices, SampleRateHz)
+	echoIndices := ultrasignal.DetectEchoes(envelopeHilbert, params.EchoThreshold*params.FullScale)
+	// Зондирующий импульс в начале блока — не эхо: мёртвая зона отсчитывается
+	// от начала входа, то есть сдвигается на задержку фильтра.
+	echoIndices = ultrasignal.EchoesFrom(echoIndices, filtered.Delay+params.DeadZoneUS*1e-6*params.SampleRateHz)
+	// Огибающая Гильберта не сдвигает сигнал, поэтому вычитается только задержка фильтра.
+	tof := filtered.TimeOfFlight(echoIndices, params.SampleRateHz)
 	log.Printf("⏱️ Time of Flight: %.9f секунд", tof)
 
 	log.Println("6️⃣ Расчёт спектра с использованием FFT")
-	windowed := ultrasignal.HammingWindow(filteredSignal[:min(len(filteredSignal), FFTKernelSize)])
-	frequencies, spectrum := ultrasignal.ComputeFFTLog(windowed, SampleRateHz, math.Pow(10.0, -3.0), math.Pow(10.0, 6), FFTKernelSize)
-	if err := storage.SaveSpectrum(FilePath+FileWithFreq+"_Signal_spectrum.csv", frequencies, spectrum); err != nil {
+	windowed := ultrasignal.HammingWindow(filteredSignal[:min(len(filteredSignal), params.FFTKernelSize)])
+	frequencies, spectrum := ultrasignal.ComputeFFTLog(windowed, params.SampleRateHz, params.SpectrumMinHz, params.SpectrumMaxHz, params.FFTKernelSize)
+	if err := storage.SaveSpectrum(FilePath+FileWithFreq+"_Signal_spectrum"+suffix+".csv", frequencies, spectrum); err != nil {
 		log.Printf("❌ Spectrum save error: %v", err)
 	}
 
@@ -136,26 +252,40 @@ func processing(data []float64) {
 	var phaseVel []float64
 	var groupVel []float64
 	for _, freq := range frequencies {
-		phaseVelC := ultrasignal.PhaseVelocity(freq, Thickness, Mode)
-		groupVelC := ultrasignal.GroupVelocity(freq, Thickness, Mode)
+		phaseVelC := ultrasignal.PhaseVelocity(freq, params.ThicknessMM, params.Mode)
+		groupVelC := ultrasignal.GroupVelocity(freq, params.ThicknessMM, params.Mode)
 
 		phaseVel = append(phaseVel, phaseVelC)
 		groupVel = append(groupVel, groupVelC)
 	}
 
-	if err := storage.SaveSample(FilePath+FileWithTime+"_PhaseVelocity"+".csv", phaseVel); err != nil {
+	if err := storage.SaveSample(FilePath+FileWithTime+"_PhaseVelocity"+suffix+".csv", phaseVel); err != nil {
 		log.Printf("❌ Phase velocity save error: %v", err)
 	}
-	if err := storage.SaveSample(FilePath+FileWithTime+"_GroupVelocity"+".csv", groupVel); err != nil {
+	if err := storage.SaveSample(FilePath+FileWithTime+"_GroupVelocity"+suffix+".csv", groupVel); err != nil {
 		log.Printf("❌ Group velocity save error: %v", err)
 	}
 
 	log.Println("Анализ проведен")
-	time.Sleep(ultrasignal.FreqToTime(CurrentSampleRateHz))
+	time.Sleep(ultrasignal.FreqToTime(params.SourceRateHz))
+	return tof, nil
 }
 
-func bToMb(b uint64) uint64 {
-	return b
+// filterResponse вычисляет частотную характеристику ядра, применённого
+// способом params.FilterPhase, на сетке afc_scale: линейной от 0 или
+// логарифмической от afc_min_hz до частоты Найквиста.
+func filterResponse(kernel []float64, params processingParams) (ultrasignal.FilterResponse, error) {
+	scale := ultrasignal.GridScale(params.AFCScale)
+	fMin := 0.0
+	if scale == ultrasignal.GridLog {
+		fMin = params.AFCMinHz
+	}
+	freqs, err := ultrasignal.FrequencyGrid(scale, fMin, params.SampleRateHz/2, params.AFCPoints)
+	if err != nil {
+		return ultrasignal.FilterResponse{}, err
+	}
+	filter := ultrasignal.AppliedFIR{Kernel: kernel, Phase: ultrasignal.FilterPhase(params.FilterPhase)}
+	return ultrasignal.FrequencyResponse(filter, freqs, params.SampleRateHz), nil
 }
 
 func logSettings() (*os.File, error) {
@@ -168,3 +298,37 @@ func logSettings() (*os.File, error) {
 
 	return logFile, nil
 }
+
+// stepper выдаёт шаг воспроизведения на каждую строку стандартного ввода.
+func stepper() <-chan struct{} {
+	step := make(chan struct{})
+	go func() {
+		defer close(step)
+		scanner := bufio.NewScanner(os.Stdin)
+		log.Println("⏭ Пошаговое воспроизведение: Enter — следующий кадр")
+		for scanner.Scan() {
+			step <- struct{}{}
+		}
+	}()
+	return step
+}
+
+// parseFloats разбирает список чисел через запятую длиной n; пустая строка — nil.
+func parseFloats(list string, n int) ([]float64, error) {
+	if list == "" {
+		return nil, nil
+	}
+	fields := strings.Split(list, ",")
+	if len(fields) != n {
+		return nil, fmt.Errorf("got %d values for %d channels", len(fields), n)
+	}
+	values := make([]float64, n)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"fpga-ultrasound-go/memory"
+)
+
+// simScript возвращает n кадров симулятора в сырых словах окна w.
+func simScript(t *testing.T, w memory.Window, dec *memory.Decoder, n int) [][]uint16 {
+	t.Helper()
+	cfg := memory.DefaultSimConfig()
+	cfg.Seed = 5
+	src := memory.NewSimulatedSource(cfg, w, 0)
+	if err := src.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	script := make([][]uint16, n)
+	for i := range script {
+		var frame memory.Frame
+		if err := src.Next(context.Background(), &frame); err != nil {
+			t.Fatal(err)
+		}
+		script[i] = memory.EncodeFrame(dec, frame.Samples)
+	}
+	return script
+}
+
+// runScript проигрывает сценарий через поддельный /dev/mem и настоящий
+// источник devmem и возвращает отсчёты want блоков из очереди обработки
+// (блок — один кадр). Выходные файлы сбора пишутся в текущий каталог.
+func runScript(t *testing.T, w memory.Window, script [][]uint16, want int) [][][]float64 {
+	t.Helper()
+	fake, err := memory.NewFakeDevMem(t.TempDir(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+
+	source, err := memory.NewSource(memory.Config{Source: memory.SourceDevMem, Path: fake.Path(), Window: w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := testPipeline(t, ctx, source, w)
+	defer p.tracker.Close()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		p.acquire(ctx)
+	}()
+	fake.Play(ctx, script, 0)
+
+	frames := make([][][]float64, want)
+	for i := range frames {
+		b := <-p.queue.blocks
+		for _, samples := range b.Samples {
+			frames[i] = append(frames[i], slices.Clone(samples))
+		}
+		p.queue.release(b)
+	}
+	cancel()
+	<-stopped
+	return frames
+}
+
+// testPipeline открывает источник и собирает стадии сбора без записи,
+// калибровки и накопления; блок обработки — один кадр.
+func testPipeline(t *testing.T, ctx context.Context, source memory.FrameSource, w memory.Window) *pipeline {
+	t.Helper()
+	tracker := memory.NewTracker(source, "fake")
+	if err := tracker.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	decoder, err := memory.NewDecoder(w.Encoding, w.FullScaleVolts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue, err := newBlockQueue(DefaultSettings().QueueDepth, BackpressureBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pipeline{
+		tracker: tracker,
+		monitor: memory.NewHealthMonitor(memory.DefaultHealthConfig(), decoder),
+		decoder: decoder,
+		layout:  memory.SingleChannel(),
+
+		queue:        queue,
+		blockSize:    w.FrameSize,
+		sampleRateHz: DefaultSettings().SampleRateHz,
+	}
+}
+
+func quietLog(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+func readCSV(t *testing.T, name string) [][]string {
+	t.Helper()
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return records
+}
+
+func TestAcquireAndProcess(t *testing.T) {
+	quietLog(t)
+	t.Chdir(t.TempDir())
+
+	w := memory.DefaultWindow()
+	w.HeaderBytes = 4
+	w.PingPong = true
+	dec, err := memory.NewDecoder(w.Encoding, w.FullScaleVolts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := simScript(t, w, dec, 4)
+	frames := runScript(t, w, script, len(script))
+
+	var data []float64
+	for i, frame := range frames {
+		if len(frame) != 1 || len(frame[0]) != w.FrameSize {
+			t.Fatalf("frame %d: %d channels", i+1, len(frame))
+		}
+		for j, v := range frame[0] {
+			if v != dec.Volts(script[i][j]) {
+				t.Fatalf("frame %d sample %d = %g, want %g", i+1, j, v, dec.Volts(script[i][j]))
+			}
+		}
+		data = append(data, frame[0]...)
+	}
+	if rows := readCSV(t, FileWithTime+"_frames.csv"); len(rows) != len(script)+1 {
+		t.Fatalf("frame log has %d rows, want header and %d frames", len(rows), len(script))
+	}
+
+	settings := DefaultSettings()
+	_, err = processing(context.Background(), data, processingParams{
+		Settings:  settings,
+		LowHz:     settings.LowCutoffHz,
+		HighHz:    settings.HighCutoffHz,
+		Unit:      memory.UnitADCVolts,
+		FullScale: w.FullScaleVolts,
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		FileWithTime + "_FIR_result.csv",
+		FileWithTime + "_Envelope_via_Hilbert.csv",
+		FileWithFreq + "_Signal_spectrum.csv",
+		FileWithFreq + "_filter_frequency_response.csv",
+		FileWithTime + "_PhaseVelocity.csv",
+		FileWithTime + "_GroupVelocity.csv",
+	} {
+		if len(readCSV(t, name)) == 0 {
+			t.Errorf("%s is empty", name)
+		}
+	}
+	if rows := readCSV(t, FileWithTime+"_FIR_result.csv"); len(rows) != len(data) {
+		t.Errorf("FIR result has %d rows, want %d", len(rows), len(data))
+	}
+}
+
+func TestAcquireDropsStuckFrames(t *testing.T) {
+	quietLog(t)
+	t.Chdir(t.TempDir())
+
+	w := memory.DefaultWindow()
+	w.HeaderBytes = 4
+	w.PingPong = true
+	dec, err := memory.NewDecoder(w.Encoding, w.FullScaleVolts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := simScript(t, w, dec, 3)
+	// Второй кадр — незагруженная прошивка: все слова 0xFF.
+	stuck := make([]uint16, w.FrameSize)
+	for i := range stuck {
+		stuck[i] = 0xFF
+	}
+	script = append(script[:1], append([][]uint16{stuck}, script[1:]...)...)
+
+	frames := runScript(t, w, script, 3)
+	for i, frame := range frames {
+		if frame[0][0] == dec.Volts(0xFF) && frame[0][1] == dec.Volts(0xFF) {
+			t.Fatalf("stuck frame reached processing as frame %d", i+1)
+		}
+	}
+	// Отброшенный кадр не попадает в журнал и сырые отсчёты.
+	if rows := readCSV(t, FileWithTime+"_frames.csv"); len(rows) != 4 {
+		t.Fatalf("frame log has %d rows, want header and 3 frames", len(rows))
+	}
+	if rows := readCSV(t, FileWithTime+"_RAW_result.csv"); len(rows) != 3*w.FrameSize {
+		t.Fatalf("raw csv has %d rows, want %d", len(rows), 3*w.FrameSize)
+	}
+}
+
+func TestStreamingProcessesEveryBlock(t *testing.T) {
+	quietLog(t)
+	t.Chdir(t.TempDir())
+
+	// Сессия из нескольких кадров: источник заканчивается io.EOF,
+	// и обработка должна завершиться сама, разобрав каждый блок.
+	w := memory.DefaultWindow()
+	cfg := memory.DefaultSimConfig()
+	cfg.Seed = 1
+	sim := memory.NewSimulatedSource(cfg, w, 0)
+	if err := sim.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	path := filepath.Join(t.TempDir(), "scan.uses")
+	recorder, err := memory.NewRecorder(path, "sim", memory.Config{Window: w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := memory.NewTracker(sim, "sim")
+	const frames = 5
+	for i := 0; i < frames; i++ {
+		var frame memory.Frame
+		if err := tracker.Next(context.Background(), &frame); err != nil {
+			t.Fatal(err)
+		}
+		if err := recorder.Write(&frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	p := testPipeline(t, ctx, memory.NewSessionSource(path, memory.ReplayFast, nil, false), w)
+	defer p.tracker.Close()
+	go p.acquire(ctx)
+
+	settings := DefaultSettings()
+	p.process(ctx, processingParams{
+		Settings:  settings,
+		LowHz:     settings.LowCutoffHz,
+		HighHz:    settings.HighCutoffHz,
+		Unit:      memory.UnitADCVolts,
+		FullScale: w.FullScaleVolts,
+	})
+
+	rows := readCSV(t, FileWithTime+"_TimeOfFlight.csv")
+	if len(rows) != frames+1 {
+		t.Fatalf("time of flight has %d rows, want header and %d blocks", len(rows), frames)
+	}
+	// Время пролёта — до первого эха модели после зондирующего импульса;
+	// допуск — длительность пачки.
+	want := cfg.TriggerDelay + cfg.Echoes[0].Delay
+	tolerance := cfg.BurstCycles / cfg.BurstFreqHz
+	for i, row := range rows[1:] {
+		if row[0] != strconv.Itoa(i+1) || row[2] != "ch1" {
+			t.Fatalf("row %d = %v", i+1, row)
+		}
+		tof, err := strconv.ParseFloat(row[3], 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(tof-want) > tolerance {
+			t.Errorf("row %d: time of flight %g s, want %g ± %g s", i+1, tof, want, tolerance)
+		}
+	}
+}
+
+func defaultParams() processingParams {
+	settings := DefaultSettings()
+	return processingParams{
+		Settings:  settings,
+		LowHz:     settings.LowCutoffHz,
+		HighHz:    settings.HighCutoffHz,
+		Unit:      memory.UnitADCVolts,
+		FullScale: memory.DefaultFullScale,
+	}
+}
+
+func TestShutdownDrainsAcquiredFrames(t *testing.T) {
+	quietLog(t)
+	t.Chdir(t.TempDir())
+
+	w := memory.DefaultWindow()
+	ctx, cancel := context.WithCancel(context.Background())
+	p := testPipeline(t, ctx, memory.NewSimulatedSource(memory.DefaultSimConfig(), w, 0), w)
+	defer p.tracker.Close()
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		p.acquire(ctx)
+	}()
+	time.AfterFunc(50*time.Millisecond, cancel)
+	p.process(context.Background(), defaultParams())
+	<-acquired
+
+	// Каждый принятый кадр обработан: сигнал останавливает только чтение.
+	s := p.summary("sim", time.Now())
+	if s.StopReason != stopSignal {
+		t.Fatalf("stop reason = %q, want %q", s.StopReason, stopSignal)
+	}
+	if s.Frames == 0 || s.Blocks != s.Frames-s.HealthDropped || s.BlocksSkipped != 0 {
+		t.Fatalf("summary = %+v, want every frame processed", s)
+	}
+	if rows := readCSV(t, FileWithTime+"_TimeOfFlight.csv"); uint64(len(rows)-1) != s.Blocks {
+		t.Fatalf("time of flight has %d rows, want %d", len(rows)-1, s.Blocks)
+	}
+}
+
+func TestShutdownTimeoutSkipsBlocks(t *testing.T) {
+	quietLog(t)
+	t.Chdir(t.TempDir())
+
+	q, err := newBlockQueue(4, BackpressureBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pipeline{queue: q, layout: memory.SingleChannel()}
+	for i := 0; i < 3; i++ {
+		b := q.get(1)
+		b.Samples[0] = append(b.Samples[0], make([]float64, 1024)...)
+		q.blocks <- b
+	}
+	q.close()
+
+	// Время на остановку истекло: блоки извлекаются, но не обрабатываются.
+	drain, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.process(drain, defaultParams())
+	if p.processed != 0 || p.skipped != 3 {
+		t.Fatalf("processed %d, skipped %d, want 0 and 3", p.processed, p.skipped)
+	}
+	if _, err := os.Stat(FileWithTime + "_TimeOfFlight.csv"); !os.IsNotExist(err) {
+		t.Fatalf("skipped blocks produced results: %v", err)
+	}
+}
+
+func TestProcessCountsFailedBlocks(t *testing.T) {
+	quietLog(t)
+	t.Chdir(t.TempDir())
+
+	q, err := newBlockQueue(4, BackpressureBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pipeline{queue: q, layout: memory.SingleChannel()}
+	for i := 0; i < 2; i++ {
+		b := q.get(1)
+		b.Samples[0] = append(b.Samples[0], make([]float64, 1024)...)
+		q.blocks <- b
+	}
+	q.close()
+
+	// Ошибка обработки не считается обработанным блоком и не даёт времени пролёта.
+	params := defaultParams()
+	params.FilterPhase = "bogus"
+	p.process(context.Background(), params)
+	if p.processed != 0 || p.failed != 2 || p.skipped != 0 {
+		t.Fatalf("processed %d, failed %d, skipped %d, want 0, 2 and 0", p.processed, p.failed, p.skipped)
+	}
+	if _, err := os.Stat(FileWithTime + "_TimeOfFlight.csv"); !os.IsNotExist(err) {
+		t.Fatalf("failed blocks produced time of flight: %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package memory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// AverageMode — способ когерентного накопления кадров.
+type AverageMode string
+
+const (
+	// AverageMean — скользящее среднее последних N кадров.
+	AverageMean AverageMode = "mean"
+	// AverageExponential — экспоненциальное среднее y += α·(x − y).
+	AverageExponential AverageMode = "exp"
+	// AverageMedian — поотсчётная медиана последних N кадров, устойчива к единичным выбросам.
+	AverageMedian AverageMode = "median"
+)
+
+// AverageConfig — параметры накопления.
+type AverageConfig struct {
+	Mode  AverageMode
+	N     int     // глубина окна для mean и median, для exp — длительность разгона
+	Alpha float64 // коэффициент экспоненциального среднего, 0 < α ≤ 1 (0 — 2/(N+1))
+
+	// Кадр принимается, только если зондирующий импульс в нём стоит там же,
+	// где в первом принятом кадре, с точностью до MaxJitter отсчётов.
+	// Смещение находится по максимуму взаимной корреляции с импульсом опорного кадра.
+	TriggerLevel float64 // порог фронта импульса в долях пика |x − среднее|
+	MaxJitter    int     // допустимое смещение импульса, отсчёты
+}
+
+const (
+	syncHalfWidth      = 32  // полуширина опорного участка вокруг импульса, отсчёты
+	minSyncCorrelation = 0.8 // нижняя граница коэффициента корреляции с опорным импульсом
+)
+
+// DefaultAverageConfig возвращает скользящее среднее 16 кадров.
+func DefaultAverageConfig() AverageConfig {
+	return AverageConfig{Mode: AverageMean, N: 16, TriggerLevel: 0.5, MaxJitter: 1}
+}
+
+// Validate проверяет параметры накопления.
+func (c AverageConfig) Validate() error {
+	switch c.Mode {
+	case AverageMean, AverageExponential, AverageMedian:
+	default:
+		return fmt.Errorf("unknown average mode %q", c.Mode)
+	}
+	if c.N < 1 {
+		return fmt.Errorf("invalid average depth %d", c.N)
+	}
+	if c.Alpha < 0 || c.Alpha > 1 {
+		return fmt.Errorf("invalid average alpha %g", c.Alpha)
+	}
+	if c.TriggerLevel <= 0 || c.TriggerLevel >= 1 {
+		return fmt.Errorf("invalid trigger level %g, want (0, 1)", c.TriggerLevel)
+	}
+	if c.MaxJitter < 0 {
+		return fmt.Errorf("invalid trigger jitter %d", c.MaxJitter)
+	}
+	return nil
+}
+
+// AverageStats — счётчики и выигрыш накопления.
+type AverageStats struct {
+	Accepted   uint64  // кадры, вошедшие в среднее
+	Rejected   uint64  // кадры с несовпавшим импульсом или длиной
+	Depth      int     // кадры в текущем среднем
+	Trigger    int     // опорное положение зондирующего импульса, отсчёт
+	ExpectedDB float64 // теоретический выигрыш в ОСШ для белого шума, дБ
+	MeasuredDB float64 // выигрыш по шуму до импульса: 20·lg(σ кадра / σ среднего), дБ; NaN, если оценить нельзя
+}
+
+// Averager когерентно накапливает кадры для повышения отношения сигнал/шум.
+//
+// Для некоррелированного шума среднее N кадров снижает σ в √N раз
+// (выигрыш 10·lg N дБ), экспоненциальное среднее эквивалентно
+// N = (2 − α)/α кадрам, медиана — примерно 2N/π кадрам.
+type Averager struct {
+	cfg     AverageConfig
+	alpha   float64
+	trigger int       // -1, пока нет опорного кадра
+	sync    []float64 // опорный импульс без постоянной составляющей
+
+	history [][]float64 // кольцо последних N кадров для mean и median
+	next    int
+	depth   int
+	sum     []float64
+	avg     []float64
+	scratch []float64
+	last    []float64 // последний принятый кадр для оценки шума
+
+	accepted uint64
+	rejected uint64
+}
+
+// NewAverager создаёт накопитель.
+func NewAverager(cfg AverageConfig) (*Averager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	alpha := cfg.Alpha
+	if alpha == 0 {
+		alpha = 2 / float64(cfg.N+1)
+	}
+	return &Averager{cfg: cfg, alpha: alpha, trigger: -1}, nil
+}
+
+// Add добавляет кадр и сообщает, принят ли он.
+// Кадры с другой длиной или смещённым зондирующим импульсом отбрасываются.
+func (a *Averager) Add(frame *Frame) bool {
+	samples := frame.Samples
+	trigger := TriggerIndex(samples, a.cfg.TriggerLevel)
+	if trigger < 0 || (a.avg != nil && len(samples) != len(a.avg)) {
+		a.rejected++
+		return false
+	}
+	if a.trigger < 0 {
+		a.init(samples, trigger)
+	} else if lag, corr := a.align(samples); corr < minSyncCorrelation || abs(lag) > a.cfg.MaxJitter {
+		a.rejected++
+		return false
+	}
+
+	a.accepted++
+	a.last = append(a.last[:0], samples...)
+	switch a.cfg.Mode {
+	case AverageExponential:
+		if a.depth == 0 {
+			copy(a.avg, samples)
+		} else {
+			for i, v := range samples {
+				a.avg[i] += a.alpha * (v - a.avg[i])
+			}
+		}
+		a.depth = min(a.depth+1, a.cfg.N)
+	case AverageMean:
+		slot := a.push(samples)
+		for i, v := range samples {
+			a.sum[i] += v - slot[i]
+			a.avg[i] = a.sum[i] / float64(a.depth)
+		}
+	case AverageMedian:
+		a.push(samples)
+		for i := range a.avg {
+			a.scratch = a.scratch[:0]
+			for _, h := range a.history[:a.depth] {
+				a.scratch = append(a.scratch, h[i])
+			}
+			a.avg[i] = median(a.scratch)
+		}
+	}
+	return true
+}
+
+func (a *Averager) init(samples []float64, trigger int) {
+	n := len(samples)
+	a.trigger = trigger
+	from, to := max(trigger-syncHalfWidth, 0), min(trigger+syncHalfWidth, n)
+	a.sync = append(a.sync[:0], samples[from:to]...)
+	mean := meanOf(a.sync)
+	for i := range a.sync {
+		a.sync[i] -= mean
+	}
+
+	a.avg = make([]float64, n)
+	if a.cfg.Mode == AverageExponential {
+		return
+	}
+	a.sum = make([]float64, n)
+	a.history = make([][]float64, a.cfg.N)
+	for i := range a.history {
+		a.history[i] = make([]float64, n)
+	}
+}
+
+// align ищет сдвиг импульса относительно опорного в пределах ±2·(MaxJitter+1)
+// отсчётов и возвращает его вместе с нормированным коэффициентом корреляции.
+func (a *Averager) align(samples []float64) (lag int, corr float64) {
+	from := max(a.trigger-syncHalfWidth, 0)
+	search := 2 * (a.cfg.MaxJitter + 1)
+	refEnergy := 0.0
+	for _, r := range a.sync {
+		refEnergy += r * r
+	}
+
+	corr = math.Inf(-1)
+	for l := -search; l <= search; l++ {
+		start := from + l
+		if start < 0 || start+len(a.sync) > len(samples) {
+			continue
+		}
+		segment := samples[start : start+len(a.sync)]
+		mean := meanOf(segment)
+		dot, energy := 0.0, 0.0
+		for j, r := range a.sync {
+			x := segment[j] - mean
+			dot += r * x
+			energy += x * x
+		}
+		if energy == 0 || refEnergy == 0 {
+			continue
+		}
+		if c := dot / math.Sqrt(energy*refEnergy); c > corr {
+			lag, corr = l, c
+		}
+	}
+	return lag, corr
+}
+
+// push кладёт кадр в кольцо и возвращает вытесненный кадр (нули, пока кольцо не заполнено).
+func (a *Averager) push(samples []float64) []float64 {
+	slot := a.history[a.next]
+	old := append(a.scratch[:0], slot...)
+	copy(slot, samples)
+	a.next = (a.next + 1) % len(a.history)
+	if a.depth < len(a.history) {
+		a.depth++
+		clear(old)
+	}
+	a.scratch = old
+	return old
+}
+
+// Ready сообщает, что окно накопления заполнено.
+func (a *Averager) Ready() bool {
+	return a.depth >= a.cfg.N
+}
+
+// Result копирует текущее среднее в dst[:0].
+func (a *Averager) Result(dst []float64) []float64 {
+	return append(dst[:0], a.avg...)
+}
+
+// Reset сбрасывает накопление и опорное положение импульса.
+func (a *Averager) Reset() {
+	a.trigger = -1
+	a.sync, a.avg, a.sum, a.history, a.last = nil, nil, nil, nil, nil
+	a.next, a.depth = 0, 0
+}
+
+// Stats возвращает счётчики и оценки выигрыша.
+func (a *Averager) Stats() AverageStats {
+	return AverageStats{
+		Accepted:   a.accepted,
+		Rejected:   a.rejected,
+		Depth:      a.depth,
+		Trigger:    a.trigger,
+		ExpectedDB: 10 * math.Log10(a.effectiveFrames()),
+		MeasuredDB: a.measuredGain(),
+	}
+}
+
+func (a *Averager) effectiveFrames() float64 {
+	n := float64(max(a.depth, 1))
+	switch a.cfg.Mode {
+	case AverageExponential:
+		// Пока фильтр не разогнался, он ближе к простому среднему.
+		return math.Min(n, (2-a.alpha)/a.alpha)
+	case AverageMedian:
+		if a.depth < 3 {
+			return n
+		}
+		return 2 * n / math.Pi
+	default:
+		return n
+	}
+}
+
+// measuredGain сравнивает шум до зондирующего импульса в последнем кадре и в среднем.
+// Берётся первая половина участка до порога: ближе к порогу уже нарастает фронт импульса.
+func (a *Averager) measuredGain() float64 {
+	quiet := (a.trigger - a.cfg.MaxJitter) / 2
+	if a.depth == 0 || quiet < 8 {
+		return math.NaN()
+	}
+	raw, avg := stddev(a.last[:quiet]), stddev(a.avg[:quiet])
+	if avg == 0 {
+		return math.Inf(1)
+	}
+	return 20 * math.Log10(raw/avg)
+}
+
+// TriggerIndex возвращает первый отсчёт, где |x − среднее| достигает
+// level от максимума, или -1 для кадра без импульса.
+func TriggerIndex(samples []float64, level float64) int {
+	if len(samples) == 0 {
+		return -1
+	}
+	mean := meanOf(samples)
+	peak := 0.0
+	for _, v := range samples {
+		peak = math.Max(peak, math.Abs(v-mean))
+	}
+	if peak == 0 {
+		return -1
+	}
+	for i, v := range samples {
+		if math.Abs(v-mean) >= level*peak {
+			return i
+		}
+	}
+	return -1
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+func meanOf(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func stddev(values []float64) float64 {
+	mean := meanOf(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
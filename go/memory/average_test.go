@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func simFrames(t *testing.T, n int, noise float64) []Frame {
+	t.Helper()
+	cfg := DefaultSimConfig()
+	cfg.NoiseRMS = noise
+	cfg.ADCBits = 0
+	cfg.Seed = 7
+	cfg.TriggerDelay = 300e-6 // длинный участок шума до импульса для оценки выигрыша
+	w := DefaultWindow()
+	w.Encoding = EncodingS16
+	src := openSim(t, cfg, w)
+
+	frames := make([]Frame, n)
+	for i := range frames {
+		if err := src.Next(context.Background(), &frames[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return frames
+}
+
+func TestAveragerSNRGain(t *testing.T) {
+	for _, mode := range []AverageMode{AverageMean, AverageExponential, AverageMedian} {
+		t.Run(string(mode), func(t *testing.T) {
+			cfg := DefaultAverageConfig()
+			cfg.Mode = mode
+			averager, err := NewAverager(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, frame := range simFrames(t, 64, 0.01) {
+				if !averager.Add(&frame) {
+					t.Fatalf("frame %d rejected", i)
+				}
+			}
+			if !averager.Ready() {
+				t.Fatal("averager is not ready after 64 frames")
+			}
+			stats := averager.Stats()
+			// Оценка по шуму одного кадра грубая, допуск 2 дБ.
+			if math.Abs(stats.MeasuredDB-stats.ExpectedDB) > 2 {
+				t.Fatalf("measured gain %.1f dB, expected %.1f dB", stats.MeasuredDB, stats.ExpectedDB)
+			}
+		})
+	}
+}
+
+func TestAveragerRejectsMisalignedFrames(t *testing.T) {
+	averager, err := NewAverager(DefaultAverageConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := simFrames(t, 3, 0.001)
+	if !averager.Add(&frames[0]) {
+		t.Fatal("reference frame rejected")
+	}
+
+	shifted := Frame{Samples: append(make([]float64, 10), frames[1].Samples[:len(frames[1].Samples)-10]...)}
+	if averager.Add(&shifted) {
+		t.Fatal("frame with a shifted trigger was accepted")
+	}
+	silent := Frame{Samples: make([]float64, len(frames[1].Samples))}
+	if averager.Add(&silent) {
+		t.Fatal("frame without a trigger was accepted")
+	}
+	if !averager.Add(&frames[2]) {
+		t.Fatal("aligned frame rejected")
+	}
+	if stats := averager.Stats(); stats.Accepted != 2 || stats.Rejected != 2 {
+		t.Fatalf("accepted %d, rejected %d; want 2 and 2", stats.Accepted, stats.Rejected)
+	}
+}
+
+func TestAveragerMedianIgnoresOutlier(t *testing.T) {
+	cfg := DefaultAverageConfig()
+	cfg.Mode = AverageMedian
+	cfg.N = 5
+	averager, err := NewAverager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := simFrames(t, 5, 0)
+	// Помеха после зондирующего импульса, меньше его пика, чтобы кадр не был отброшен.
+	frames[2].Samples[500] += 0.1
+	for i := range frames {
+		averager.Add(&frames[i])
+	}
+	if got := averager.Result(nil)[500]; math.Abs(got-frames[0].Samples[500]) > 1e-12 {
+		t.Fatalf("median at the spike = %g, want %g", got, frames[0].Samples[500])
+	}
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Unit — единица, в которой выражены отсчёты кадра.
+type Unit string
+
+const (
+	// UnitCounts — отсчёты АЦП в единицах младшего разряда.
+	UnitCounts Unit = "counts"
+	// UnitADCVolts — напряжение на входе АЦП по номинальной шкале окна
+	// (Window.FullScaleVolts); так кадры выдают все источники.
+	UnitADCVolts Unit = "V_adc"
+	// UnitVolts — калиброванное напряжение на входе приёмника:
+	// учтены смещение и крутизна АЦП, нелинейность и усиление приёмного тракта.
+	UnitVolts Unit = "V"
+	// UnitFullScale — доля полной шкалы АЦП.
+	UnitFullScale Unit = "FS"
+)
+
+// Calibration — модель перевода отсчётов АЦП конкретной платы в вольты:
+//
+//	x' = LUT(x)                          — поправка нелинейности
+//	Uадц = (x' − OffsetCounts) · VoltsPerCount
+//	U = Uадц / 10^(ReceiverGainDB/20)    — напряжение на входе приёмника
+type Calibration struct {
+	Board          string  `json:"board"`
+	Serial         string  `json:"serial"`
+	OffsetCounts   float64 `json:"offset_counts"`    // отсчёт при нулевом входе
+	VoltsPerCount  float64 `json:"volts_per_count"`  // крутизна АЦП, В на единицу младшего разряда
+	ReceiverGainDB float64 `json:"receiver_gain_db"` // усиление приёмного тракта до АЦП, дБ
+	// LUT — пары (измеренный отсчёт, истинный отсчёт) по возрастанию; между
+	// точками — линейная интерполяция, за краями — продолжение крайних отрезков.
+	LUT [][2]float64 `json:"lut,omitempty"`
+}
+
+// Validate проверяет параметры калибровки.
+func (c *Calibration) Validate() error {
+	if c.VoltsPerCount <= 0 {
+		return fmt.Errorf("invalid volts per count %g", c.VoltsPerCount)
+	}
+	if len(c.LUT) == 1 {
+		return errors.New("calibration LUT needs at least two points")
+	}
+	for i := 1; i < len(c.LUT); i++ {
+		if c.LUT[i][0] <= c.LUT[i-1][0] {
+			return fmt.Errorf("calibration LUT codes must increase, got %g after %g", c.LUT[i][0], c.LUT[i-1][0])
+		}
+	}
+	return nil
+}
+
+// Linearize применяет поправку нелинейности к отсчёту.
+func (c *Calibration) Linearize(count float64) float64 {
+	if len(c.LUT) < 2 {
+		return count
+	}
+	// Первая точка с кодом больше count; края продолжают крайние отрезки.
+	i := sort.Search(len(c.LUT), func(i int) bool { return c.LUT[i][0] > count })
+	i = min(max(i, 1), len(c.LUT)-1)
+	a, b := c.LUT[i-1], c.LUT[i]
+	return a[1] + (count-a[0])*(b[1]-a[1])/(b[0]-a[0])
+}
+
+// Volts переводит отсчёт АЦП в напряжение на входе приёмника.
+func (c *Calibration) Volts(count float64) float64 {
+	adc := (c.Linearize(count) - c.OffsetCounts) * c.VoltsPerCount
+	return adc / math.Pow(10, c.ReceiverGainDB/20)
+}
+
+// FullScale возвращает размах входа приёмника в вольтах, соответствующий
+// полной шкале формата декодера.
+func (c *Calibration) FullScale(dec *Decoder) float64 {
+	lo, hi := dec.CountRange()
+	return math.Abs(c.Volts(hi) - c.Volts(lo))
+}
+
+// Apply переводит кадр из номинальных вольт АЦП в калиброванные вольты
+// на входе приёмника. Отсчёты восстанавливаются через декодер окна, поэтому
+// калибровка применима и к воспроизведённой записи сессии.
+func (c *Calibration) Apply(frame *Frame, dec *Decoder) error {
+	if frame.Unit != UnitADCVolts {
+		return fmt.Errorf("calibration expects %s samples, got %q", UnitADCVolts, frame.Unit)
+	}
+	for i, v := range frame.Samples {
+		frame.Samples[i] = c.Volts(dec.Count(dec.Encode(v)))
+	}
+	frame.Unit = UnitVolts
+	return nil
+}
+
+// ReadCalibration читает калибровку из JSON-файла.
+func ReadCalibration(path string) (*Calibration, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s failed: %w", path, err)
+	}
+	var cal Calibration
+	if err := json.Unmarshal(raw, &cal); err != nil {
+		return nil, fmt.Errorf("parse %s failed: %w", path, err)
+	}
+	if err := cal.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cal, nil
+}
+
+// LoadCalibration ищет калибровку платы в каталоге dir:
+// сначала dir/<board>/<serial>.json, затем общий для модели dir/<board>/default.json.
+// Плата и серийный номер в файле, если указаны, должны совпадать с запрошенными.
+func LoadCalibration(dir, board, serial string) (*Calibration, error) {
+	if board == "" {
+		return nil, errors.New("calibration lookup requires a board name")
+	}
+	var candidates []string
+	if serial != "" {
+		candidates = append(candidates, filepath.Join(dir, board, serial+".json"))
+	}
+	candidates = append(candidates, filepath.Join(dir, board, "default.json"))
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		cal, err := ReadCalibration(path)
+		if err != nil {
+			return nil, err
+		}
+		if cal.Board != "" && cal.Board != board {
+			return nil, fmt.Errorf("%s: calibration is for board %q, not %q", path, cal.Board, board)
+		}
+		if cal.Serial != "" && serial != "" && cal.Serial != serial {
+			return nil, fmt.Errorf("%s: calibration is for serial %q, not %q", path, cal.Serial, serial)
+		}
+		return cal, nil
+	}
+	return nil, fmt.Errorf("no calibration for board %q serial %q in %s", board, serial, dir)
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCalibrationVolts(t *testing.T) {
+	cal := &Calibration{
+		OffsetCounts:   2048,
+		VoltsPerCount:  0.001,
+		ReceiverGainDB: 20,
+		// Верхняя половина шкалы сжата: измеренный 3072 соответствует истинному 3200.
+		LUT: [][2]float64{{0, 0}, {2048, 2048}, {3072, 3200}, {4095, 4095}},
+	}
+	if err := cal.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		count, want float64
+	}{
+		{2048, 0},
+		{1048, -0.1},                   // линейный участок: −1000 отсчётов · 1 мВ / 10
+		{3072, 0.1152},                 // (3200 − 2048) · 1 мВ / 10
+		{2560, (2624 - 2048) * 0.0001}, // середина отрезка LUT
+	} {
+		if got := cal.Volts(tc.count); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("Volts(%g) = %g, want %g", tc.count, got, tc.want)
+		}
+	}
+}
+
+func TestCalibrationApply(t *testing.T) {
+	dec, err := NewDecoder(EncodingU12Left, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := &Calibration{OffsetCounts: 2048, VoltsPerCount: 0.5e-3}
+	frame := Frame{
+		Unit:    UnitADCVolts,
+		Samples: []float64{dec.Volts(2048 << 4), dec.Volts(4095 << 4), dec.Volts(0)},
+	}
+	if err := cal.Apply(&frame, dec); err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0, 2047 * 0.5e-3, -2048 * 0.5e-3}
+	for i := range want {
+		if math.Abs(frame.Samples[i]-want[i]) > 1e-12 {
+			t.Fatalf("sample %d = %g, want %g", i, frame.Samples[i], want[i])
+		}
+	}
+	if frame.Unit != UnitVolts {
+		t.Fatalf("unit = %q, want %q", frame.Unit, UnitVolts)
+	}
+	if err := cal.Apply(&frame, dec); err == nil {
+		t.Fatal("calibration applied twice")
+	}
+	if fs := cal.FullScale(dec); math.Abs(fs-4095*0.5e-3) > 1e-12 {
+		t.Fatalf("full scale = %g", fs)
+	}
+}
+
+func TestLoadCalibration(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		t.Helper()
+		path := filepath.Join(dir, "de10", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("default.json", `{"board":"de10","offset_counts":32768,"volts_per_count":3e-5}`)
+	write("A17.json", `{"board":"de10","serial":"A17","offset_counts":32700,"volts_per_count":3.1e-5,"receiver_gain_db":12}`)
+	write("B02.json", `{"board":"de10","serial":"X99","volts_per_count":3e-5}`)
+
+	cal, err := LoadCalibration(dir, "de10", "A17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cal.OffsetCounts != 32700 || cal.ReceiverGainDB != 12 {
+		t.Fatalf("serial calibration = %+v", cal)
+	}
+	if cal, err = LoadCalibration(dir, "de10", "C33"); err != nil || cal.OffsetCounts != 32768 {
+		t.Fatalf("board default = %+v, %v", cal, err)
+	}
+	if _, err := LoadCalibration(dir, "de10", "B02"); err == nil {
+		t.Fatal("calibration with a mismatched serial was accepted")
+	}
+	if _, err := LoadCalibration(dir, "de1-soc", ""); err == nil {
+		t.Fatal("calibration for an unknown board was found")
+	}
+}
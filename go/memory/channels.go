@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+)
+
+// Interleave — порядок отсчётов нескольких каналов в окне захвата.
+type Interleave string
+
+const (
+	// InterleaveSample — отсчёты каналов чередуются: s0c0, s0c1, …, s1c0, s1c1, …
+	InterleaveSample Interleave = "sample"
+	// InterleaveBlock — каналы идут блоками: все отсчёты c0, затем все отсчёты c1, …
+	InterleaveBlock Interleave = "block"
+)
+
+// MaxChannels — наибольшее число приёмных каналов в одном окне.
+const MaxChannels = 8
+
+// Channel — описание приёмного канала.
+//
+// Отсчёт канала переводится как (x − Offset)·Gain, где x — отсчёт кадра
+// в его единицах (Frame.Unit): Offset убирает постоянную составляющую
+// тракта, Gain выравнивает усиление каналов.
+type Channel struct {
+	Name   string  // например, «tx1-rx2» для пары pitch-catch
+	Slot   int     // позиция канала в чередовании окна
+	Gain   float64 // множитель, 0 трактуется как 1
+	Offset float64 // смещение нуля в единицах кадра
+}
+
+// ChannelLayout — раскладка каналов в окне захвата.
+type ChannelLayout struct {
+	Interleave Interleave
+	Channels   []Channel
+}
+
+// SingleChannel возвращает раскладку одного канала — прежнее поведение.
+func SingleChannel() ChannelLayout {
+	return NewChannelLayout(1, InterleaveSample)
+}
+
+// NewChannelLayout создаёт раскладку из n каналов «ch1»…«chN» в естественном порядке
+// с единичным усилением.
+func NewChannelLayout(n int, interleave Interleave) ChannelLayout {
+	layout := ChannelLayout{Interleave: interleave, Channels: make([]Channel, n)}
+	for i := range layout.Channels {
+		layout.Channels[i] = Channel{Name: fmt.Sprintf("ch%d", i+1), Slot: i, Gain: 1}
+	}
+	return layout
+}
+
+// Validate проверяет раскладку для кадра из frameSize отсчётов всех каналов.
+func (l ChannelLayout) Validate(frameSize int) error {
+	n := len(l.Channels)
+	if n < 1 || n > MaxChannels {
+		return fmt.Errorf("invalid channel count %d, want 1..%d", n, MaxChannels)
+	}
+	switch l.Interleave {
+	case InterleaveSample, InterleaveBlock:
+	default:
+		return fmt.Errorf("unknown channel interleave %q", l.Interleave)
+	}
+	if frameSize%n != 0 {
+		return fmt.Errorf("frame size %d is not a multiple of %d channels", frameSize, n)
+	}
+	used := make([]bool, n)
+	for _, ch := range l.Channels {
+		if ch.Slot < 0 || ch.Slot >= n || used[ch.Slot] {
+			return fmt.Errorf("channel %q has invalid or duplicate slot %d", ch.Name, ch.Slot)
+		}
+		used[ch.Slot] = true
+	}
+	return nil
+}
+
+// ChannelFrame — кадр, разделённый по каналам.
+type ChannelFrame struct {
+	Seq       uint64    // номер исходного кадра
+	Timestamp time.Time // момент захвата
+	SourceID  string
+	Dropped   uint64
+	Unit      Unit        // единица отсчётов всех каналов
+	Channels  []Channel   // метаданные каналов, в том же порядке, что и Samples
+	Samples   [][]float64 // отсчёты каждого канала в единицах Unit
+}
+
+// Deinterleave разделяет кадр на каналы по раскладке, применяя усиление и смещение.
+// Буферы dst.Samples переиспользуются.
+func (l ChannelLayout) Deinterleave(frame *Frame, dst *ChannelFrame) error {
+	n := len(l.Channels)
+	if n == 0 || len(frame.Samples)%n != 0 {
+		return fmt.Errorf("frame of %d samples does not split into %d channels", len(frame.Samples), n)
+	}
+	perChannel := len(frame.Samples) / n
+
+	dst.Seq = frame.Seq
+	dst.Timestamp = frame.Timestamp
+	dst.SourceID = frame.SourceID
+	dst.Dropped = frame.Dropped
+	dst.Unit = frame.Unit
+	dst.Channels = l.Channels
+	if cap(dst.Samples) < n {
+		dst.Samples = make([][]float64, n)
+	}
+	dst.Samples = dst.Samples[:n]
+
+	for c, ch := range l.Channels {
+		gain := ch.Gain
+		if gain == 0 {
+			gain = 1
+		}
+		out := dst.Samples[c][:0]
+		for i := 0; i < perChannel; i++ {
+			var x float64
+			if l.Interleave == InterleaveBlock {
+				x = frame.Samples[ch.Slot*perChannel+i]
+			} else {
+				x = frame.Samples[i*n+ch.Slot]
+			}
+			out = append(out, (x-ch.Offset)*gain)
+		}
+		dst.Samples[c] = out
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeinterleave(t *testing.T) {
+	// Три канала по два отсчёта: значение = 10·канал + отсчёт.
+	sample := []float64{0, 10, 20, 1, 11, 21}
+	block := []float64{0, 1, 10, 11, 20, 21}
+
+	for _, tc := range []struct {
+		name   string
+		layout ChannelLayout
+		frame  []float64
+	}{
+		{"sample", NewChannelLayout(3, InterleaveSample), sample},
+		{"block", NewChannelLayout(3, InterleaveBlock), block},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out ChannelFrame
+			if err := tc.layout.Deinterleave(&Frame{Seq: 7, Samples: tc.frame}, &out); err != nil {
+				t.Fatal(err)
+			}
+			want := [][]float64{{0, 1}, {10, 11}, {20, 21}}
+			for c := range want {
+				if !slices.Equal(out.Samples[c], want[c]) {
+					t.Fatalf("channel %d = %v, want %v", c, out.Samples[c], want[c])
+				}
+			}
+			if out.Seq != 7 || out.Channels[2].Name != "ch3" {
+				t.Fatalf("metadata = %d %+v", out.Seq, out.Channels)
+			}
+		})
+	}
+}
+
+func TestDeinterleaveGainOffsetAndSlots(t *testing.T) {
+	layout := ChannelLayout{
+		Interleave: InterleaveSample,
+		Channels: []Channel{
+			{Name: "rx", Slot: 1, Gain: 2, Offset: 0.5},
+			{Name: "tx", Slot: 0},
+		},
+	}
+	if err := layout.Validate(4); err != nil {
+		t.Fatal(err)
+	}
+
+	var out ChannelFrame
+	if err := layout.Deinterleave(&Frame{Samples: []float64{1, 1.5, 2, 2.5}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(out.Samples[0], []float64{2, 4}) || !slices.Equal(out.Samples[1], []float64{1, 2}) {
+		t.Fatalf("channels = %v", out.Samples)
+	}
+
+	// Буферы каналов переиспользуются.
+	first := &out.Samples[0][0]
+	if err := layout.Deinterleave(&Frame{Samples: []float64{0, 0, 0, 0}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if &out.Samples[0][0] != first {
+		t.Fatal("channel buffer was reallocated")
+	}
+}
+
+func TestChannelLayoutValidate(t *testing.T) {
+	duplicate := NewChannelLayout(2, InterleaveSample)
+	duplicate.Channels[1].Slot = 0
+
+	for name, tc := range map[string]struct {
+		layout    ChannelLayout
+		frameSize int
+	}{
+		"too many":     {NewChannelLayout(9, InterleaveSample), 9 * 4},
+		"uneven frame": {NewChannelLayout(3, InterleaveSample), 1024},
+		"duplicate":    {duplicate, 1024},
+		"interleave":   {NewChannelLayout(2, "word"), 1024},
+	} {
+		if err := tc.layout.Validate(tc.frameSize); err == nil {
+			t.Errorf("%s: Validate succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"unsafe"
+
+	mmap "github.com/edsrzf/mmap-go"
+)
+
+// Device — долгоживущее отображение окна захвата в память процесса.
+//
+// Окно отображается один раз при OpenDevice и остаётся доступным до Close,
+// поэтому чтение кадра не требует системных вызовов.
+type Device struct {
+	path   string
+	file   *os.File
+	mem    mmap.MMap
+	window []byte
+	write  bool
+}
+
+// OpenDevice открывает path (/dev/mem, /dev/uioN или обычный файл)
+// и отображает length байт начиная со смещения offset.
+// Смещение не обязано быть выровнено по странице.
+func OpenDevice(path string, offset int64, length int) (*Device, error) {
+	alignedOffset := offset & ^int64(PageSize-1)
+	return openDevice(path, alignedOffset, int(offset-alignedOffset), length, false)
+}
+
+// OpenDeviceRW открывает окно так же, как OpenDevice, но с правом записи.
+// Используется для регистров FPGA за мостом HPS-to-FPGA.
+func OpenDeviceRW(path string, offset int64, length int) (*Device, error) {
+	alignedOffset := offset & ^int64(PageSize-1)
+	return openDevice(path, alignedOffset, int(offset-alignedOffset), length, true)
+}
+
+// openDevice отображает skip+length байт начиная с выровненного смещения
+// mapOffset и оставляет окно [skip, skip+length). Для UIO смещение
+// отображения кодирует номер области, поэтому его нельзя сдвигать.
+func openDevice(path string, mapOffset int64, skip, length int, write bool) (*Device, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("invalid window length %d", length)
+	}
+
+	flags := os.O_RDONLY
+	if write {
+		flags = os.O_RDWR
+	}
+	file, err := os.OpenFile(path, flags|os.O_SYNC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open %s failed: %w", path, err)
+	}
+
+	prot := mmap.RDONLY
+	if write {
+		prot = mmap.RDWR
+	}
+	mem, err := mmap.MapRegion(file, skip+length, prot, 0, mapOffset)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("mmap failed: %w", err)
+	}
+
+	return &Device{
+		path:   path,
+		file:   file,
+		mem:    mem,
+		window: mem[skip : skip+length],
+		write:  write,
+	}, nil
+}
+
+// Bytes возвращает окно без копирования. Срез действителен до Close,
+// а его содержимое может меняться под записью со стороны FPGA.
+func (d *Device) Bytes() []byte {
+	return d.window
+}
+
+// Len возвращает размер окна в байтах.
+func (d *Device) Len() int {
+	return len(d.window)
+}
+
+// ReadRaw копирует отсчёты uint16 (little-endian) из окна в dst
+// и возвращает число скопированных отсчётов.
+func (d *Device) ReadRaw(dst []uint16) int {
+	n := min(len(dst), len(d.window)/2)
+	for i := 0; i < n; i++ {
+		dst[i] = binary.LittleEndian.Uint16(d.window[i*2:])
+	}
+	return n
+}
+
+// ReadFrame разбирает окно w: аппаратный счётчик из заголовка (если он есть)
+// и отсчёты, которые декодируются в frame.Samples за один проход
+// с переиспользованием буфера кадра.
+func (d *Device) ReadFrame(w Window, dec *Decoder, frame *Frame) error {
+	return d.ReadFrameAt(0, w, dec, frame)
+}
+
+// ReadFrameAt разбирает кадр, начинающийся со смещения off.
+func (d *Device) ReadFrameAt(off int, w Window, dec *Decoder, frame *Frame) error {
+	if size := w.ByteLen(); off < 0 || off+size > len(d.window) {
+		return fmt.Errorf("frame of %d bytes at %#x exceeds window of %d bytes", size, off, len(d.window))
+	}
+
+	frame.HasCounter = w.HeaderBytes >= 4
+	if frame.HasCounter {
+		counter, err := d.Load32(off)
+		if err != nil {
+			return err
+		}
+		frame.Counter = counter
+	}
+	frame.Samples = dec.Decode(d.window[off+w.HeaderBytes:], w.FrameSize, frame.Samples)
+	frame.Unit = UnitADCVolts
+	return nil
+}
+
+// Load32 читает 32-битное слово по смещению off одной шинной транзакцией.
+func (d *Device) Load32(off int) (uint32, error) {
+	p, err := d.word(off)
+	if err != nil {
+		return 0, err
+	}
+	return atomic.LoadUint32(p), nil
+}
+
+// Store32 записывает 32-битное слово по смещению off одной шинной транзакцией.
+func (d *Device) Store32(off int, val uint32) error {
+	if !d.write {
+		return fmt.Errorf("%s is mapped read-only", d.path)
+	}
+	p, err := d.word(off)
+	if err != nil {
+		return err
+	}
+	atomic.StoreUint32(p, val)
+	return nil
+}
+
+// word возвращает указатель на выровненное 32-битное слово окна.
+func (d *Device) word(off int) (*uint32, error) {
+	return wordAt(d.window, off)
+}
+
+// wordAt возвращает указатель на выровненное 32-битное слово буфера buf.
+func wordAt(buf []byte, off int) (*uint32, error) {
+	if off < 0 || off+4 > len(buf) {
+		return nil, fmt.Errorf("offset %#x is outside window of %d bytes", off, len(buf))
+	}
+	p := unsafe.Pointer(&buf[off])
+	if uintptr(p)%4 != 0 {
+		return nil, fmt.Errorf("offset %#x is not 32-bit aligned", off)
+	}
+	return (*uint32)(p), nil
+}
+
+// Close снимает отображение и закрывает устройство.
+func (d *Device) Close() error {
+	if d.mem == nil {
+		return nil
+	}
+	err := d.mem.Unmap()
+	d.mem = nil
+	d.window = nil
+	if cerr := d.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("close %s failed: %w", d.path, err)
+	}
+	return nil
+}
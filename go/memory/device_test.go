@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeMemFile создаёт разреженный файл, повторяющий раскладку /dev/mem
+// до конца окна захвата.
+func fakeMemFile(tb testing.TB) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "mem")
+	file, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer file.Close()
+	if err := file.Truncate(DefaultBaseAddress + DefaultFrameSize*2); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func defaultDecoder(tb testing.TB) *Decoder {
+	tb.Helper()
+	decoder, err := NewDecoder(EncodingU16, DefaultFullScale)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return decoder
+}
+
+func BenchmarkDeviceReadFrame(b *testing.B) {
+	device, err := OpenDevice(fakeMemFile(b), DefaultBaseAddress, DefaultFrameSize*2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer device.Close()
+
+	decoder := defaultDecoder(b)
+	var frame Frame
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := device.ReadFrame(DefaultWindow(), decoder, &frame); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeviceReadRaw(b *testing.B) {
+	device, err := OpenDevice(fakeMemFile(b), DefaultBaseAddress, DefaultFrameSize*2)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer device.Close()
+
+	raw := make([]uint16, DefaultFrameSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		device.ReadRaw(raw)
+	}
+}
+
+// BenchmarkRemapPerFrame повторяет прежнее поведение ReadFrame:
+// open, mmap, чтение и munmap на каждый кадр.
+func BenchmarkRemapPerFrame(b *testing.B) {
+	path := fakeMemFile(b)
+
+	decoder := defaultDecoder(b)
+	var frame Frame
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		device, err := OpenDevice(path, DefaultBaseAddress, DefaultFrameSize*2)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := device.ReadFrame(DefaultWindow(), decoder, &frame); err != nil {
+			b.Fatal(err)
+		}
+		device.Close()
+	}
+}
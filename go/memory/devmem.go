@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+)
+
+// DevMemSource читает окно физической памяти через /dev/mem.
+// Окно отображается один раз в Open и снимается в Close.
+// Для окна PingPong кадры читаются только из заполненных буферов.
+type DevMemSource struct {
+	path     string
+	window   Window
+	decoder  *Decoder
+	device   *Device
+	pingPong *PingPong
+}
+
+// NewDevMemSource создаёт источник поверх /dev/mem (или указанного файла).
+func NewDevMemSource(path string, window Window) *DevMemSource {
+	if path == "" {
+		path = "/dev/mem"
+	}
+	return &DevMemSource{path: path, window: window}
+}
+
+func (s *DevMemSource) Open(ctx context.Context) error {
+	decoder, err := NewDecoder(s.window.Encoding, s.window.FullScaleVolts)
+	if err != nil {
+		return err
+	}
+	open := OpenDevice
+	if s.window.PingPong {
+		// Подтверждения буферов пишутся в то же окно.
+		open = OpenDeviceRW
+	}
+	device, err := open(s.path, s.window.BaseAddress, s.window.MapLen())
+	if err != nil {
+		return err
+	}
+	return s.attach(device, decoder)
+}
+
+func (s *DevMemSource) Next(ctx context.Context, frame *Frame) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.device == nil {
+		return fmt.Errorf("devmem source %s is not open", s.path)
+	}
+	if s.pingPong != nil {
+		return s.pingPong.Next(ctx, s.decoder, frame)
+	}
+	return s.device.ReadFrame(s.window, s.decoder, frame)
+}
+
+func (s *DevMemSource) Close() error {
+	if s.device == nil {
+		return nil
+	}
+	err := s.device.Close()
+	s.device = nil
+	s.pingPong = nil
+	return err
+}
+
+// attach запоминает открытое окно и, для PingPong, создаёт читателя буферов.
+func (s *DevMemSource) attach(device *Device, decoder *Decoder) error {
+	if s.window.PingPong {
+		pingPong, err := NewPingPong(device, s.window, DefaultPingPongTimeout)
+		if err != nil {
+			device.Close()
+			return err
+		}
+		s.pingPong = pingPong
+	}
+	s.decoder = decoder
+	s.device = device
+	return nil
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FakeDevMem — разреженный файл с раскладкой /dev/mem до конца окна захвата
+// и писатель кадров в него. Источники devmem читают файл тем же кодом mmap,
+// что и физическую память, поэтому цепочку сбора можно проверить на любой машине.
+//
+// Для окна PingPong кадры пишутся через PingPongModel и не теряются:
+// следующий кадр ждёт свободного буфера. Для простого окна кадр
+// записывается поверх предыдущего, а счётчик в заголовке (если он есть)
+// увеличивается после записи отсчётов.
+type FakeDevMem struct {
+	path    string
+	window  Window
+	device  *Device
+	model   *PingPongModel
+	counter uint32
+}
+
+// NewFakeDevMem создаёт в каталоге dir файл «mem» размером до конца окна w.
+func NewFakeDevMem(dir string, w Window) (*FakeDevMem, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "mem")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create %s failed: %w", path, err)
+	}
+	// Truncate не выделяет блоки: адрес окна в десятки мегабайт не занимает места на диске.
+	err = file.Truncate(w.BaseAddress + int64(w.MapLen()))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("truncate %s failed: %w", path, err)
+	}
+
+	device, err := OpenDeviceRW(path, w.BaseAddress, w.MapLen())
+	if err != nil {
+		return nil, err
+	}
+	fake := &FakeDevMem{path: path, window: w, device: device}
+	if w.PingPong {
+		if fake.model, err = NewPingPongModel(device.Bytes(), w); err != nil {
+			device.Close()
+			return nil, err
+		}
+	}
+	return fake, nil
+}
+
+// Path возвращает путь к файлу для Config.Path.
+func (f *FakeDevMem) Path() string {
+	return f.path
+}
+
+// Window возвращает окно, под которое размечен файл.
+func (f *FakeDevMem) Window() Window {
+	return f.window
+}
+
+// WriteFrame записывает кадр из сырых 16-битных слов отсчётов.
+// Для окна PingPong возвращает false, если оба буфера заняты и кадр пропущен.
+func (f *FakeDevMem) WriteFrame(words []uint16) bool {
+	if f.model != nil {
+		return f.model.Write(words)
+	}
+	buf := f.device.Bytes()
+	samples := buf[f.window.HeaderBytes:f.window.ByteLen()]
+	for i := 0; i < len(words) && 2*i+2 <= len(samples); i++ {
+		binary.LittleEndian.PutUint16(samples[2*i:], words[i])
+	}
+	f.counter++
+	if f.window.HeaderBytes >= 4 {
+		counter, _ := wordAt(buf, 0)
+		atomic.StoreUint32(counter, f.counter)
+	}
+	return true
+}
+
+// Play записывает кадры script в отдельной горутине с паузой interval
+// между ними и закрывает возвращённый канал, когда сценарий окончен
+// или ctx отменён. Для окна PingPong каждый кадр ждёт свободного буфера.
+func (f *FakeDevMem) Play(ctx context.Context, script [][]uint16, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i, words := range script {
+			if i > 0 && interval > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+			}
+			for f.model != nil && f.model.Busy() {
+				if ctx.Err() != nil {
+					return
+				}
+				time.Sleep(pingPongPollInterval)
+			}
+			f.WriteFrame(words)
+		}
+	}()
+	return done
+}
+
+// Close снимает отображение; файл удаляется вместе с каталогом.
+func (f *FakeDevMem) Close() error {
+	return f.device.Close()
+}
+
+// EncodeFrame переводит кадр в вольтах АЦП в сырые слова окна для декодера dec.
+func EncodeFrame(dec *Decoder, samples []float64) []uint16 {
+	words := make([]uint16, len(samples))
+	for i, v := range samples {
+		words[i] = dec.Encode(v)
+	}
+	return words
+}
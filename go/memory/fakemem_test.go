@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeDevMem создаёт поддельный /dev/mem под окно w.
+func fakeDevMem(t *testing.T, w Window) *FakeDevMem {
+	t.Helper()
+	fake, err := NewFakeDevMem(t.TempDir(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { fake.Close() })
+	return fake
+}
+
+// scriptFrames возвращает n кадров симулятора в вольтах АЦП.
+func scriptFrames(t *testing.T, w Window, n int) [][]float64 {
+	t.Helper()
+	cfg := DefaultSimConfig()
+	cfg.Seed = 3
+	src := openSim(t, cfg, w)
+	frames := make([][]float64, n)
+	for i := range frames {
+		var frame Frame
+		if err := src.Next(context.Background(), &frame); err != nil {
+			t.Fatal(err)
+		}
+		frames[i] = frame.Samples
+	}
+	return frames
+}
+
+func TestFakeDevMemSingleBuffer(t *testing.T) {
+	w := DefaultWindow()
+	w.HeaderBytes = 4
+	fake := fakeDevMem(t, w)
+	dec := defaultDecoder(t)
+
+	src := NewDevMemSource(fake.Path(), w)
+	if err := src.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	want := scriptFrames(t, w, 1)[0]
+	fake.WriteFrame(EncodeFrame(dec, want))
+
+	var frame Frame
+	if err := src.Next(context.Background(), &frame); err != nil {
+		t.Fatal(err)
+	}
+	if !frame.HasCounter || frame.Counter != 1 {
+		t.Fatalf("counter = %d (%t), want 1", frame.Counter, frame.HasCounter)
+	}
+	for i, v := range want {
+		if frame.Samples[i] != dec.Volts(dec.Encode(v)) {
+			t.Fatalf("sample %d = %g, want %g", i, frame.Samples[i], v)
+		}
+	}
+}
+
+func TestFakeDevMemScript(t *testing.T) {
+	w := DefaultWindow()
+	w.HeaderBytes = 4
+	w.PingPong = true
+	fake := fakeDevMem(t, w)
+	dec := defaultDecoder(t)
+
+	script := scriptFrames(t, w, 20)
+	words := make([][]uint16, len(script))
+	for i, samples := range script {
+		words[i] = EncodeFrame(dec, samples)
+	}
+
+	source, err := NewSource(Config{Source: SourceDevMem, Path: fake.Path(), Window: w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := NewTracker(source, "fake")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := tracker.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Close()
+	done := fake.Play(ctx, words, 0)
+
+	var frame Frame
+	for i, samples := range script {
+		if err := tracker.Next(ctx, &frame); err != nil {
+			t.Fatal(err)
+		}
+		if frame.Seq != uint64(i+1) || frame.Dropped != 0 || frame.Repeated {
+			t.Fatalf("frame %d: seq %d dropped %d repeated %t", i+1, frame.Seq, frame.Dropped, frame.Repeated)
+		}
+		for j, v := range samples {
+			if frame.Samples[j] != dec.Volts(dec.Encode(v)) {
+				t.Fatalf("frame %d sample %d = %g, want %g", i+1, j, frame.Samples[j], v)
+			}
+		}
+	}
+	<-done
+	if stats := tracker.Stats(); stats.Dropped != 0 {
+		t.Fatalf("stats = %+v, want no drops", stats)
+	}
+}
@@ -0,0 +1,288 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// HealthCheck — проверка исправности кадра.
+type HealthCheck string
+
+const (
+	// CheckSaturation — отсчёты на границе шкалы АЦП.
+	CheckSaturation HealthCheck = "saturation"
+	// CheckStuck — кадр почти целиком из одного значения: обрыв кабеля,
+	// незагруженная прошивка (0xFF из fpga.v) или нули.
+	CheckStuck HealthCheck = "stuck"
+	// CheckNoBang — нет зондирующего импульса в начале кадра.
+	CheckNoBang HealthCheck = "no_bang"
+	// CheckDCOffset — среднее кадра далеко от середины шкалы.
+	CheckDCOffset HealthCheck = "dc_offset"
+	// CheckNoise — СКЗ шума в хвосте кадра вне допустимых пределов.
+	CheckNoise HealthCheck = "noise"
+)
+
+// HealthChecks — все проверки в порядке выполнения.
+var HealthChecks = []HealthCheck{CheckSaturation, CheckStuck, CheckNoBang, CheckDCOffset, CheckNoise}
+
+// HealthAction — реакция на непройденную проверку. Действия упорядочены по строгости.
+type HealthAction string
+
+const (
+	ActionOff  HealthAction = "off"  // проверка не выполняется
+	ActionLog  HealthAction = "log"  // кадр проходит дальше, сбой только учитывается
+	ActionDrop HealthAction = "drop" // кадр отбрасывается
+	ActionHalt HealthAction = "halt" // сбор данных останавливается
+)
+
+func (a HealthAction) severity() int {
+	switch a {
+	case ActionLog:
+		return 1
+	case ActionDrop:
+		return 2
+	case ActionHalt:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ErrHealthHalt возвращается, когда непройденная проверка требует остановить сбор.
+var ErrHealthHalt = errors.New("acquisition halted by health check")
+
+// HealthConfig — пороги и действия проверок. Пороги заданы в долях полной шкалы АЦП,
+// поэтому не зависят от формата отсчётов и калибровки.
+type HealthConfig struct {
+	Actions map[HealthCheck]HealthAction
+
+	MaxClipped    float64 // доля отсчётов на границе шкалы, сверх которой кадр насыщен
+	StuckFraction float64 // доля самого частого значения, начиная с которой кадр «залип»
+	BangWindow    int     // зондирующий импульс ищется в первых BangWindow отсчётах (0 — четверть кадра)
+	MinBang       float64 // наименьший пик импульса относительно медианы кадра
+	MaxDCOffset   float64 // наибольшее отклонение среднего от середины шкалы
+	NoiseWindow   int     // шум оценивается по последним NoiseWindow отсчётам (0 — восьмая часть кадра)
+	MinNoiseRMS   float64 // нижняя граница СКЗ шума (0 — не проверять)
+	MaxNoiseRMS   float64 // верхняя граница СКЗ шума
+}
+
+// DefaultHealthConfig возвращает проверки, при которых пустые и залипшие
+// кадры отбрасываются, а остальные сбои попадают в журнал.
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		Actions: map[HealthCheck]HealthAction{
+			CheckSaturation: ActionLog,
+			CheckStuck:      ActionDrop,
+			CheckNoBang:     ActionDrop,
+			CheckDCOffset:   ActionLog,
+			CheckNoise:      ActionLog,
+		},
+		StuckFraction: 0.9,
+		MinBang:       0.05,
+		MaxDCOffset:   0.1,
+		MaxNoiseRMS:   0.05,
+	}
+}
+
+// ParseHealthActions разбирает список «проверка=действие» через запятую
+// и накладывает его на действия cfg.
+func ParseHealthActions(cfg *HealthConfig, list string) error {
+	if list == "" {
+		return nil
+	}
+	actions := make(map[HealthCheck]HealthAction, len(cfg.Actions))
+	for check, action := range cfg.Actions {
+		actions[check] = action
+	}
+	for _, item := range strings.Split(list, ",") {
+		check, action, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return fmt.Errorf("health action %q is not check=action", item)
+		}
+		if !slices.Contains(HealthChecks, HealthCheck(check)) {
+			return fmt.Errorf("unknown health check %q", check)
+		}
+		if HealthAction(action) != ActionOff && HealthAction(action).severity() == 0 {
+			return fmt.Errorf("unknown health action %q", action)
+		}
+		actions[HealthCheck(check)] = HealthAction(action)
+	}
+	cfg.Actions = actions
+	return nil
+}
+
+// HealthFailure — непройденная проверка.
+type HealthFailure struct {
+	Check  HealthCheck
+	Action HealthAction
+	Detail string
+}
+
+// HealthReport — итог проверки кадра.
+type HealthReport struct {
+	Failures []HealthFailure
+	Action   HealthAction // самое строгое действие среди сбоев, пусто — кадр исправен
+}
+
+// OK сообщает, что кадр прошёл все проверки.
+func (r HealthReport) OK() bool {
+	return len(r.Failures) == 0
+}
+
+func (r HealthReport) String() string {
+	parts := make([]string, len(r.Failures))
+	for i, f := range r.Failures {
+		parts[i] = fmt.Sprintf("%s (%s): %s", f.Check, f.Action, f.Detail)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// HealthStats — счётчики проверок.
+type HealthStats struct {
+	Frames   uint64                 // проверенные кадры
+	Dropped  uint64                 // кадры, отброшенные по результатам проверок
+	Halted   bool                   // сбор остановлен
+	Failures map[HealthCheck]uint64 // сбои по каждой проверке
+}
+
+// HealthMonitor проверяет кадры источника до калибровки: пороги считаются
+// в отсчётах АЦП, которые восстанавливаются декодером окна.
+type HealthMonitor struct {
+	cfg    HealthConfig
+	dec    *Decoder
+	lo, hi float64 // границы шкалы, отсчёты
+	counts []float64
+	sorted []float64
+	stats  HealthStats
+}
+
+// NewHealthMonitor создаёт монитор для кадров в формате декодера dec.
+func NewHealthMonitor(cfg HealthConfig, dec *Decoder) *HealthMonitor {
+	lo, hi := dec.CountRange()
+	return &HealthMonitor{
+		cfg:   cfg,
+		dec:   dec,
+		lo:    lo,
+		hi:    hi,
+		stats: HealthStats{Failures: make(map[HealthCheck]uint64)},
+	}
+}
+
+// Check проверяет кадр в номинальных вольтах АЦП и обновляет счётчики.
+// Для ActionHalt возвращается ошибка с ErrHealthHalt.
+func (m *HealthMonitor) Check(frame *Frame) (HealthReport, error) {
+	if frame.Unit != UnitADCVolts {
+		return HealthReport{}, fmt.Errorf("health check expects %s samples, got %q", UnitADCVolts, frame.Unit)
+	}
+	var report HealthReport
+	m.stats.Frames++
+	if len(frame.Samples) == 0 {
+		return report, nil
+	}
+
+	m.counts = m.counts[:0]
+	for _, v := range frame.Samples {
+		m.counts = append(m.counts, m.dec.Count(m.dec.Encode(v)))
+	}
+	m.sorted = append(m.sorted[:0], m.counts...)
+	slices.Sort(m.sorted)
+
+	for _, check := range HealthChecks {
+		action := m.cfg.Actions[check]
+		if action.severity() == 0 {
+			continue
+		}
+		detail, failed := m.run(check)
+		if !failed {
+			continue
+		}
+		m.stats.Failures[check]++
+		report.Failures = append(report.Failures, HealthFailure{Check: check, Action: action, Detail: detail})
+		if action.severity() > report.Action.severity() {
+			report.Action = action
+		}
+	}
+
+	switch report.Action {
+	case ActionDrop:
+		m.stats.Dropped++
+	case ActionHalt:
+		m.stats.Halted = true
+		return report, fmt.Errorf("%w: frame #%d: %s", ErrHealthHalt, frame.Seq, report)
+	}
+	return report, nil
+}
+
+// run выполняет одну проверку над отсчётами m.counts.
+func (m *HealthMonitor) run(check HealthCheck) (detail string, failed bool) {
+	n := len(m.counts)
+	span := m.hi - m.lo + 1
+	switch check {
+	case CheckSaturation:
+		clipped := 0
+		for _, c := range m.counts {
+			if c <= m.lo || c >= m.hi {
+				clipped++
+			}
+		}
+		if frac := float64(clipped) / float64(n); clipped > 0 && frac > m.cfg.MaxClipped {
+			return fmt.Sprintf("%d of %d samples at full scale", clipped, n), true
+		}
+	case CheckStuck:
+		run, longest, value := 1, 1, m.sorted[0]
+		for i := 1; i < n; i++ {
+			if m.sorted[i] == m.sorted[i-1] {
+				run++
+			} else {
+				run = 1
+			}
+			if run > longest {
+				longest, value = run, m.sorted[i]
+			}
+		}
+		if frac := float64(longest) / float64(n); frac >= m.cfg.StuckFraction {
+			return fmt.Sprintf("%.0f%% of samples equal %#x", 100*frac, int64(value)), true
+		}
+	case CheckNoBang:
+		window := m.cfg.BangWindow
+		if window <= 0 {
+			window = n / 4
+		}
+		median := m.sorted[n/2]
+		peak := 0.0
+		for _, c := range m.counts[:min(window, n)] {
+			peak = math.Max(peak, math.Abs(c-median))
+		}
+		if peak/span < m.cfg.MinBang {
+			return fmt.Sprintf("peak %.3f FS in the first %d samples", peak/span, window), true
+		}
+	case CheckDCOffset:
+		mid := (m.lo + m.hi + 1) / 2
+		if offset := (meanOf(m.counts) - mid) / span; math.Abs(offset) > m.cfg.MaxDCOffset {
+			return fmt.Sprintf("mean is %+.3f FS from mid-scale", offset), true
+		}
+	case CheckNoise:
+		window := m.cfg.NoiseWindow
+		if window <= 0 {
+			window = n / 8
+		}
+		rms := stddev(m.counts[n-min(max(window, 1), n):]) / span
+		if rms < m.cfg.MinNoiseRMS || (m.cfg.MaxNoiseRMS > 0 && rms > m.cfg.MaxNoiseRMS) {
+			return fmt.Sprintf("noise RMS %.4f FS", rms), true
+		}
+	}
+	return "", false
+}
+
+// Stats возвращает копию счётчиков.
+func (m *HealthMonitor) Stats() HealthStats {
+	stats := m.stats
+	stats.Failures = make(map[HealthCheck]uint64, len(m.stats.Failures))
+	for check, n := range m.stats.Failures {
+		stats.Failures[check] = n
+	}
+	return stats
+}
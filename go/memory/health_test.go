@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// healthFrame возвращает кадр симулятора в беззнаковом формате окна по умолчанию.
+func healthFrame(t *testing.T, noise float64) *Frame {
+	t.Helper()
+	cfg := DefaultSimConfig()
+	cfg.NoiseRMS = noise
+	cfg.Seed = 7
+	src := openSim(t, cfg, DefaultWindow())
+	var frame Frame
+	if err := src.Next(context.Background(), &frame); err != nil {
+		t.Fatal(err)
+	}
+	return &frame
+}
+
+func constantFrame(dec *Decoder, code uint16) *Frame {
+	frame := &Frame{Unit: UnitADCVolts, Samples: make([]float64, DefaultFrameSize)}
+	for i := range frame.Samples {
+		frame.Samples[i] = dec.Volts(code)
+	}
+	return frame
+}
+
+func failedChecks(report HealthReport) map[HealthCheck]bool {
+	failed := make(map[HealthCheck]bool)
+	for _, f := range report.Failures {
+		failed[f.Check] = true
+	}
+	return failed
+}
+
+func TestHealthMonitorPassesSimulatedFrame(t *testing.T) {
+	monitor := NewHealthMonitor(DefaultHealthConfig(), defaultDecoder(t))
+
+	report, err := monitor.Check(healthFrame(t, 0.005))
+	if err != nil || !report.OK() {
+		t.Fatalf("healthy frame failed: %v %s", err, report)
+	}
+}
+
+func TestHealthMonitorFPGAFill(t *testing.T) {
+	dec := defaultDecoder(t)
+	monitor := NewHealthMonitor(DefaultHealthConfig(), dec)
+
+	// Незагруженная прошивка: все слова 0xFF.
+	report, err := monitor.Check(constantFrame(dec, 0xFF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := failedChecks(report)
+	if !failed[CheckStuck] || !failed[CheckNoBang] || !failed[CheckDCOffset] {
+		t.Fatalf("0xFF frame: %s", report)
+	}
+	if report.Action != ActionDrop {
+		t.Fatalf("action = %q, want drop", report.Action)
+	}
+
+	// Нули лежат на нижней границе беззнаковой шкалы.
+	report, _ = monitor.Check(constantFrame(dec, 0))
+	if !failedChecks(report)[CheckSaturation] {
+		t.Fatalf("zero frame: %s", report)
+	}
+
+	stats := monitor.Stats()
+	if stats.Frames != 2 || stats.Dropped != 2 || stats.Failures[CheckStuck] != 2 || stats.Failures[CheckSaturation] != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestHealthMonitorHalt(t *testing.T) {
+	dec := defaultDecoder(t)
+	cfg := DefaultHealthConfig()
+	if err := ParseHealthActions(&cfg, "stuck=halt, dc_offset=off"); err != nil {
+		t.Fatal(err)
+	}
+	monitor := NewHealthMonitor(cfg, dec)
+
+	report, err := monitor.Check(constantFrame(dec, 0xFF))
+	if !errors.Is(err, ErrHealthHalt) {
+		t.Fatalf("Check = %v, want ErrHealthHalt", err)
+	}
+	if failedChecks(report)[CheckDCOffset] {
+		t.Fatal("disabled check was run")
+	}
+	if !monitor.Stats().Halted {
+		t.Fatal("stats do not report the halt")
+	}
+	// Исходная конфигурация не меняется.
+	if DefaultHealthConfig().Actions[CheckStuck] != ActionDrop {
+		t.Fatal("ParseHealthActions modified the default actions")
+	}
+}
+
+func TestHealthMonitorNoise(t *testing.T) {
+	monitor := NewHealthMonitor(DefaultHealthConfig(), defaultDecoder(t))
+
+	report, _ := monitor.Check(healthFrame(t, 0.1))
+	if !failedChecks(report)[CheckNoise] {
+		t.Fatalf("noisy frame: %s", report)
+	}
+}
+
+func TestParseHealthActionsErrors(t *testing.T) {
+	for _, list := range []string{"stuck", "flat=drop", "stuck=panic"} {
+		cfg := DefaultHealthConfig()
+		if err := ParseHealthActions(&cfg, list); err == nil {
+			t.Errorf("ParseHealthActions(%q) succeeded", list)
+		}
+	}
+}
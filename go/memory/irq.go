@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrWaitTimeout возвращается, когда сигнал готовности кадра не пришёл за отведённое время.
+var ErrWaitTimeout = errors.New("frame ready wait timed out")
+
+// Waiter ждёт готовности очередного кадра.
+type Waiter interface {
+	Open(ctx context.Context) error
+	Wait(ctx context.Context) error
+	Close() error
+}
+
+// UIOWaiter ждёт прерывания от устройства Linux UIO.
+//
+// Чтение /dev/uioN блокируется до прерывания и возвращает 32-битный
+// счётчик прерываний. Перед каждым ожиданием в устройство записывается 1 —
+// так драйвер uio_pdrv_genirq снова разрешает прерывание, запрещённое
+// в обработчике. По скачкам счётчика видно пропущенные прерывания.
+type UIOWaiter struct {
+	path    string
+	timeout time.Duration
+	file    *os.File
+	arm     io.Writer // запись разрешения прерывания (irqcontrol)
+	buf     [4]byte
+
+	count   uint32
+	hasLast bool
+	missed  uint64
+}
+
+// NewUIOWaiter создаёт ожидание прерываний устройства path.
+// Нулевой timeout — ждать без ограничения.
+func NewUIOWaiter(path string, timeout time.Duration) *UIOWaiter {
+	return &UIOWaiter{path: path, timeout: timeout}
+}
+
+// newFileWaiter ждёт на уже открытом файле; arm получает записи разрешения прерывания.
+// Используется в тестах с каналом вместо устройства.
+func newFileWaiter(file *os.File, arm io.Writer, timeout time.Duration) *UIOWaiter {
+	return &UIOWaiter{path: file.Name(), timeout: timeout, file: file, arm: arm}
+}
+
+func (w *UIOWaiter) Open(ctx context.Context) error {
+	if w.file != nil {
+		return nil
+	}
+	file, err := os.OpenFile(w.path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("open %s failed: %w", w.path, err)
+	}
+	w.file = file
+	w.arm = file
+	return nil
+}
+
+// Wait разрешает прерывание и блокируется до него, тайм-аута или отмены ctx.
+func (w *UIOWaiter) Wait(ctx context.Context) error {
+	if w.file == nil {
+		return fmt.Errorf("uio waiter %s is not open", w.path)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	binary.NativeEndian.PutUint32(w.buf[:], 1)
+	if _, err := w.arm.Write(w.buf[:]); err != nil {
+		return fmt.Errorf("rearm %s failed: %w", w.path, err)
+	}
+
+	var deadline time.Time
+	if w.timeout > 0 {
+		deadline = time.Now().Add(w.timeout)
+	}
+	if err := w.file.SetReadDeadline(deadline); err != nil {
+		return fmt.Errorf("set %s deadline failed: %w", w.path, err)
+	}
+	// Отмена контекста прерывает блокирующее чтение через крайний срок.
+	stop := context.AfterFunc(ctx, func() {
+		w.file.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	if _, err := io.ReadFull(w.file, w.buf[:]); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("%w: no interrupt from %s in %v", ErrWaitTimeout, w.path, w.timeout)
+		}
+		return fmt.Errorf("read %s failed: %w", w.path, err)
+	}
+
+	count := binary.NativeEndian.Uint32(w.buf[:])
+	if w.hasLast && count-w.count > 1 {
+		w.missed += uint64(count - w.count - 1)
+	}
+	w.count = count
+	w.hasLast = true
+	return nil
+}
+
+// Count возвращает последний прочитанный счётчик прерываний.
+func (w *UIOWaiter) Count() uint32 {
+	return w.count
+}
+
+// Missed возвращает число прерываний, пропущенных между ожиданиями.
+func (w *UIOWaiter) Missed() uint64 {
+	return w.missed
+}
+
+func (w *UIOWaiter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// PollWaiter — запасной вариант без прерываний: пауза interval между чтениями.
+// С нулевым интервалом кадры читаются подряд, как раньше.
+type PollWaiter struct {
+	interval time.Duration
+}
+
+// NewPollWaiter создаёт опрос с периодом interval.
+func NewPollWaiter(interval time.Duration) *PollWaiter {
+	return &PollWaiter{interval: interval}
+}
+
+func (p *PollWaiter) Open(ctx context.Context) error {
+	return nil
+}
+
+func (p *PollWaiter) Wait(ctx context.Context) error {
+	return wait(ctx, p.interval)
+}
+
+func (p *PollWaiter) Close() error {
+	return nil
+}
+
+// WaitingSource читает кадр источника только после сигнала готовности.
+type WaitingSource struct {
+	source FrameSource
+	waiter Waiter
+}
+
+// NewWaitingSource оборачивает источник ожиданием готовности кадра.
+func NewWaitingSource(source FrameSource, waiter Waiter) *WaitingSource {
+	return &WaitingSource{source: source, waiter: waiter}
+}
+
+func (s *WaitingSource) Open(ctx context.Context) error {
+	if err := s.waiter.Open(ctx); err != nil {
+		return err
+	}
+	if err := s.source.Open(ctx); err != nil {
+		s.waiter.Close()
+		return err
+	}
+	return nil
+}
+
+// Next ждёт готовности и читает кадр. При тайм-ауте возвращается
+// ошибка с ErrWaitTimeout, источник остаётся пригодным для следующего вызова.
+func (s *WaitingSource) Next(ctx context.Context, frame *Frame) error {
+	if err := s.waiter.Wait(ctx); err != nil {
+		return err
+	}
+	return s.source.Next(ctx, frame)
+}
+
+func (s *WaitingSource) Close() error {
+	return errors.Join(s.source.Close(), s.waiter.Close())
+}
@@ -0,0 +1,110 @@
+//go:build linux
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// pipeWaiter подменяет /dev/uioN каналом: запись счётчика в w имитирует прерывание.
+func pipeWaiter(t *testing.T, timeout time.Duration) (*UIOWaiter, *os.File, *bytes.Buffer) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	arm := &bytes.Buffer{}
+	waiter := newFileWaiter(r, arm, timeout)
+	t.Cleanup(func() { waiter.Close() })
+	return waiter, w, arm
+}
+
+// irqCount — то, что драйвер uio отдаёт при чтении: счётчик прерываний.
+func irqCount(count uint32) []byte {
+	return binary.NativeEndian.AppendUint32(nil, count)
+}
+
+func interrupt(t *testing.T, w *os.File, count uint32) {
+	t.Helper()
+	if _, err := w.Write(irqCount(count)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUIOWaiterInterrupt(t *testing.T) {
+	waiter, irq, arm := pipeWaiter(t, time.Second)
+
+	time.AfterFunc(10*time.Millisecond, func() { irq.Write(irqCount(1)) })
+	if err := waiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	interrupt(t, irq, 5)
+	if err := waiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	if waiter.Count() != 5 || waiter.Missed() != 3 {
+		t.Fatalf("count %d, missed %d; want 5 and 3", waiter.Count(), waiter.Missed())
+	}
+	// Прерывание разрешается перед каждым ожиданием.
+	if got, want := arm.Len(), 2*4; got != want {
+		t.Fatalf("rearm wrote %d bytes, want %d", got, want)
+	}
+	if binary.NativeEndian.Uint32(arm.Bytes()) != 1 {
+		t.Fatalf("rearm wrote %v, want 1", arm.Bytes()[:4])
+	}
+}
+
+func TestUIOWaiterTimeout(t *testing.T) {
+	waiter, irq, _ := pipeWaiter(t, 20*time.Millisecond)
+
+	began := time.Now()
+	if err := waiter.Wait(context.Background()); !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("Wait = %v, want ErrWaitTimeout", err)
+	}
+	if elapsed := time.Since(began); elapsed < 20*time.Millisecond {
+		t.Fatalf("timed out after %v", elapsed)
+	}
+
+	// После тайм-аута ожидание снова работает.
+	interrupt(t, irq, 1)
+	if err := waiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait after timeout: %v", err)
+	}
+}
+
+func TestUIOWaiterCancel(t *testing.T) {
+	waiter, _, _ := pipeWaiter(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := waiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, want context.Canceled", err)
+	}
+}
+
+func TestWaitingSourceReadsAfterInterrupt(t *testing.T) {
+	waiter, irq, _ := pipeWaiter(t, time.Second)
+	cfg := DefaultSimConfig()
+	cfg.Seed = 1
+	source := NewWaitingSource(NewSimulatedSource(cfg, DefaultWindow(), 0), waiter)
+	if err := source.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var frame Frame
+	interrupt(t, irq, 1)
+	if err := source.Next(context.Background(), &frame); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if len(frame.Samples) != DefaultFrameSize {
+		t.Fatalf("frame has %d samples", len(frame.Samples))
+	}
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Раскладка окна PingPong: управляющий блок, затем буферы 0 и 1,
+// каждый — кадр окна (заголовок и отсчёты), выровненный по 8 байтам.
+//
+// Передача буфера построена на переключаемых битах, у каждого слова
+// ровно один писатель, поэтому обновления не теряются:
+//
+//	статус (пишет FPGA):        [0], [1] — бит заполнения буфера, переключается по окончании записи;
+//	                            [2] — номер последнего заполненного буфера
+//	подтверждение (пишет HPS):  [0], [1] — бит освобождения, переключается после чтения
+//
+// Буфер i принадлежит HPS, пока биты i статуса и подтверждения различаются.
+// FPGA пишет только в буфер, чьи биты совпадают; если заняты оба, кадр
+// пропускается и увеличивается счётчик переполнений.
+const (
+	pingPongStatusOffset   = 0
+	pingPongAckOffset      = 4
+	pingPongOverrunOffset  = 8 // кадры, пропущенные FPGA из-за занятых буферов
+	pingPongControlBytes   = 16
+	pingPongFullMask       = 0b11
+	pingPongNewestPos      = 2
+	pingPongPollInterval   = 50 * time.Microsecond
+	DefaultPingPongTimeout = time.Second
+)
+
+// bufferStride возвращает шаг буферов PingPong.
+func (w Window) bufferStride() int {
+	return (w.ByteLen() + 7) &^ 7
+}
+
+// BufferOffset возвращает смещение буфера i (0 или 1) от начала окна PingPong.
+func (w Window) BufferOffset(i int) int {
+	return pingPongControlBytes + i*w.bufferStride()
+}
+
+// PingPong читает кадры из двух буферов, которые заполняет FPGA.
+//
+// Читается только буфер, запись в который завершена, и сразу после
+// разбора он возвращается FPGA, поэтому разорванные кадры не проходят.
+// Если заполнены оба буфера, первым читается более ранний.
+type PingPong struct {
+	device  *Device
+	window  Window
+	ack     uint32 // копия слова подтверждений: его пишет только HPS
+	timeout time.Duration
+}
+
+// NewPingPong создаёт читателя поверх окна, отображённого с правом записи.
+// Next ждёт заполненного буфера не дольше timeout (0 — без ограничения).
+func NewPingPong(device *Device, w Window, timeout time.Duration) (*PingPong, error) {
+	if size := w.MapLen(); device.Len() < size {
+		return nil, fmt.Errorf("ping-pong window of %d bytes exceeds mapping of %d bytes", size, device.Len())
+	}
+	ack, err := device.Load32(pingPongAckOffset)
+	if err != nil {
+		return nil, err
+	}
+	return &PingPong{device: device, window: w, ack: ack & pingPongFullMask, timeout: timeout}, nil
+}
+
+// Next ждёт заполненного буфера, разбирает его в frame и подтверждает.
+// Если буфер не заполнился за отведённое время, возвращается ошибка
+// с ErrWaitTimeout.
+func (p *PingPong) Next(ctx context.Context, dec *Decoder, frame *Frame) error {
+	deadline := time.Now().Add(p.timeout)
+	for {
+		status, err := p.device.Load32(pingPongStatusOffset)
+		if err != nil {
+			return err
+		}
+		if buf, ok := p.ready(status); ok {
+			if err := p.device.ReadFrameAt(p.window.BufferOffset(buf), p.window, dec, frame); err != nil {
+				return err
+			}
+			p.ack ^= 1 << buf
+			return p.device.Store32(pingPongAckOffset, p.ack)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p.timeout > 0 && time.Now().After(deadline) {
+			return fmt.Errorf("%w: no ping-pong buffer filled in %v", ErrWaitTimeout, p.timeout)
+		}
+		time.Sleep(pingPongPollInterval)
+	}
+}
+
+// ready выбирает буфер для чтения по слову статуса.
+func (p *PingPong) ready(status uint32) (int, bool) {
+	switch (status ^ p.ack) & pingPongFullMask {
+	case 0b01:
+		return 0, true
+	case 0b10:
+		return 1, true
+	case 0b11:
+		// Оба заполнены: сначала тот, что заполнен раньше.
+		return 1 - int(status>>pingPongNewestPos&1), true
+	default:
+		return 0, false
+	}
+}
+
+// Overruns возвращает число кадров, пропущенных FPGA из-за занятых буферов.
+func (p *PingPong) Overruns() (uint32, error) {
+	return p.device.Load32(pingPongOverrunOffset)
+}
+
+// PingPongControl — слова управляющего блока окна PingPong.
+type PingPongControl struct {
+	Status   uint32 // биты заполнения и номер последнего буфера (пишет FPGA)
+	Ack      uint32 // биты освобождения (пишет HPS)
+	Overruns uint32 // кадры, пропущенные из-за занятых буферов
+}
+
+// Owned сообщает, принадлежит ли буфер i HPS: заполнен и ещё не подтверждён.
+func (c PingPongControl) Owned(i int) bool {
+	return (c.Status^c.Ack)>>i&1 != 0
+}
+
+// Newest возвращает номер последнего заполненного буфера.
+func (c PingPongControl) Newest() int {
+	return int(c.Status >> pingPongNewestPos & 1)
+}
+
+// ReadPingPongControl читает управляющий блок, не меняя его; окно может
+// быть отображено только для чтения.
+func ReadPingPongControl(device *Device) (PingPongControl, error) {
+	var c PingPongControl
+	var err error
+	if c.Status, err = device.Load32(pingPongStatusOffset); err != nil {
+		return c, err
+	}
+	if c.Ack, err = device.Load32(pingPongAckOffset); err != nil {
+		return c, err
+	}
+	c.Overruns, err = device.Load32(pingPongOverrunOffset)
+	return c, err
+}
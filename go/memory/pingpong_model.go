@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+)
+
+// PingPongModel — программная модель стороны FPGA протокола PingPong.
+//
+// Модель пишет кадры в область памяти так же, как прошивка: заголовок
+// со счётчиком и отсчёты в свободный буфер, затем переключает бит статуса.
+// Область обычно отображается из того же файла, что и у читателя.
+type PingPongModel struct {
+	region   []byte
+	window   Window
+	status   *uint32
+	ack      *uint32
+	overrun  *uint32
+	state    uint32 // последнее записанное слово статуса
+	counter  uint32 // аппаратный счётчик кадров, включая пропущенные
+	overruns uint32
+}
+
+// NewPingPongModel создаёт модель над областью region окна w в состоянии после сброса:
+// оба буфера свободны.
+func NewPingPongModel(region []byte, w Window) (*PingPongModel, error) {
+	if size := w.MapLen(); len(region) < size {
+		return nil, fmt.Errorf("ping-pong window of %d bytes exceeds region of %d bytes", size, len(region))
+	}
+	m := &PingPongModel{region: region, window: w}
+	var err error
+	if m.status, err = wordAt(region, pingPongStatusOffset); err != nil {
+		return nil, err
+	}
+	if m.ack, err = wordAt(region, pingPongAckOffset); err != nil {
+		return nil, err
+	}
+	if m.overrun, err = wordAt(region, pingPongOverrunOffset); err != nil {
+		return nil, err
+	}
+	m.state = atomic.LoadUint32(m.ack) & pingPongFullMask
+	atomic.StoreUint32(m.status, m.state)
+	atomic.StoreUint32(m.overrun, 0)
+	return m, nil
+}
+
+// Write записывает кадр из сырых 16-битных слов отсчётов (little-endian,
+// как в окне) и возвращает false, если оба буфера заняты и кадр пропущен.
+// Счётчик кадров увеличивается в обоих случаях.
+func (m *PingPongModel) Write(words []uint16) bool {
+	m.counter++
+	free := ^(m.state ^ atomic.LoadUint32(m.ack)) & pingPongFullMask
+	if free == 0 {
+		m.overruns++
+		atomic.StoreUint32(m.overrun, m.overruns)
+		return false
+	}
+	// Предпочитается буфер, заполненный раньше.
+	buf := 1 - int(m.state>>pingPongNewestPos&1)
+	if free&(1<<buf) == 0 {
+		buf = 1 - buf
+	}
+
+	off := m.window.BufferOffset(buf)
+	if m.window.HeaderBytes >= 4 {
+		counter, _ := wordAt(m.region, off)
+		atomic.StoreUint32(counter, m.counter)
+	}
+	samples := m.region[off+m.window.HeaderBytes : off+m.window.ByteLen()]
+	for i := 0; i < len(words) && 2*i+2 <= len(samples); i++ {
+		binary.LittleEndian.PutUint16(samples[2*i:], words[i])
+	}
+
+	m.state ^= 1 << buf
+	m.state = m.state&^(1<<pingPongNewestPos) | uint32(buf)<<pingPongNewestPos
+	atomic.StoreUint32(m.status, m.state)
+	return true
+}
+
+// Busy сообщает, что оба буфера заняты читателем и следующий кадр будет пропущен.
+func (m *PingPongModel) Busy() bool {
+	return (m.state^atomic.LoadUint32(m.ack))&pingPongFullMask == pingPongFullMask
+}
+
+// Counter возвращает значение аппаратного счётчика после последней записи.
+func (m *PingPongModel) Counter() uint32 {
+	return m.counter
+}
+
+// Overruns возвращает число пропущенных кадров.
+func (m *PingPongModel) Overruns() uint32 {
+	return m.overruns
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pingPongWindow возвращает небольшое окно PingPong со счётчиком в заголовке.
+func pingPongWindow() Window {
+	w := DefaultWindow()
+	w.BaseAddress = 0
+	w.FrameSize = 256
+	w.HeaderBytes = 4
+	w.PingPong = true
+	return w
+}
+
+// pingPongFile создаёт файл под окно w и модель FPGA, пишущую в него
+// через отдельное отображение.
+func pingPongFile(t *testing.T, w Window) (string, *PingPongModel) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mem")
+	if err := os.WriteFile(path, make([]byte, w.MapLen()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	device, err := OpenDeviceRW(path, 0, w.MapLen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { device.Close() })
+	model, err := NewPingPongModel(device.Bytes(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, model
+}
+
+// fill заполняет кадр одним значением, чтобы разорванный кадр было видно.
+func fill(words []uint16, v uint16) []uint16 {
+	for i := range words {
+		words[i] = v
+	}
+	return words
+}
+
+func TestPingPongOrderAndOverrun(t *testing.T) {
+	w := pingPongWindow()
+	path, model := pingPongFile(t, w)
+	device, err := OpenDeviceRW(path, 0, w.MapLen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+	reader, err := NewPingPong(device, w, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := defaultDecoder(t)
+	ctx := context.Background()
+
+	var frame Frame
+	if err := reader.Next(ctx, dec, &frame); !errors.Is(err, ErrWaitTimeout) {
+		t.Fatalf("empty buffers: Next = %v, want ErrWaitTimeout", err)
+	}
+
+	words := make([]uint16, w.FrameSize)
+	for v := uint16(1); v <= 3; v++ {
+		model.Write(fill(words, v))
+	}
+	if model.Overruns() != 1 {
+		t.Fatalf("overruns = %d, want 1 with both buffers full", model.Overruns())
+	}
+	if n, _ := reader.Overruns(); n != 1 {
+		t.Fatalf("reader overruns = %d, want 1", n)
+	}
+
+	// Первым читается более ранний кадр, третий пропущен прошивкой.
+	for _, want := range []uint32{1, 2} {
+		if err := reader.Next(ctx, dec, &frame); err != nil {
+			t.Fatal(err)
+		}
+		if frame.Counter != want || frame.Samples[0] != dec.Volts(uint16(want)) {
+			t.Fatalf("frame counter %d sample %g, want frame %d", frame.Counter, frame.Samples[0], want)
+		}
+	}
+
+	// Освобождённые буферы снова принимают кадры.
+	if !model.Write(fill(words, 4)) {
+		t.Fatal("write after acknowledge was rejected")
+	}
+	if err := reader.Next(ctx, dec, &frame); err != nil || frame.Counter != 4 {
+		t.Fatalf("Next = %v, counter %d, want 4", err, frame.Counter)
+	}
+}
+
+func TestPingPongNoTornFrames(t *testing.T) {
+	w := pingPongWindow()
+	path, model := pingPongFile(t, w)
+	src := NewDevMemSource(path, w)
+	if err := src.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	// Модель пишет кадры без пауз, пока читатель не наберёт нужное число.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		words := make([]uint16, w.FrameSize)
+		for n := uint32(1); ; n++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if !model.Write(fill(words, uint16(n))) {
+				// Оба буфера заняты — отдаём процессор читателю.
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	dec := defaultDecoder(t)
+	var frame Frame
+	var last uint32
+	for i := 0; i < 500; i++ {
+		if err := src.Next(context.Background(), &frame); err != nil {
+			t.Fatal(err)
+		}
+		if frame.Counter <= last {
+			t.Fatalf("frame counter %d after %d", frame.Counter, last)
+		}
+		last = frame.Counter
+		want := dec.Volts(uint16(frame.Counter))
+		for j, v := range frame.Samples {
+			if v != want {
+				t.Fatalf("torn frame %d: sample %d is %g, want %g", frame.Counter, j, v, want)
+			}
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestReadPingPongControl(t *testing.T) {
+	w := pingPongWindow()
+	path, model := pingPongFile(t, w)
+	device, err := OpenDevice(path, 0, w.MapLen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+
+	words := make([]uint16, w.FrameSize)
+	model.Write(fill(words, 1))
+	model.Write(fill(words, 2))
+	model.Write(fill(words, 3))
+	c, err := ReadPingPongControl(device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Owned(0) || !c.Owned(1) {
+		t.Errorf("owned = %t, %t, want both buffers", c.Owned(0), c.Owned(1))
+	}
+	// После сброса первым заполняется буфер 1, вторым — буфер 0.
+	if c.Newest() != 0 {
+		t.Errorf("newest = %d, want 0", c.Newest())
+	}
+	if c.Overruns != 1 {
+		t.Errorf("overruns = %d, want 1", c.Overruns)
+	}
+	if off := w.BufferOffset(1) - w.BufferOffset(0); off%8 != 0 || off < w.ByteLen() {
+		t.Errorf("buffer stride %d for frame of %d bytes", off, w.ByteLen())
+	}
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"fmt"
+
+	"fpga-ultrasound-go/sopc"
+)
+
+// WindowFromRegion привязывает окно захвата к ведомому интерфейсу из карты
+// памяти Qsys, например "onchip_memory2_0.s1": адрес берётся из .sopcinfo,
+// а размер кадра проверяется по размеру окна интерфейса.
+func WindowFromRegion(sys *sopc.System, name string, w Window) (Window, error) {
+	region, err := sys.Lookup(name)
+	if err != nil {
+		return w, err
+	}
+	if region.Span > 0 && uint64(w.MapLen()) > region.Span {
+		return w, fmt.Errorf("frame of %d bytes does not fit region %s (%d bytes)", w.MapLen(), name, region.Span)
+	}
+	w.BaseAddress = int64(region.Base)
+	return w, nil
+}
+
+// resolveWindow подставляет адрес окна из .sopcinfo, если задан регион.
+func resolveWindow(cfg Config) (Window, error) {
+	if cfg.Region == "" {
+		return cfg.Window, nil
+	}
+	if cfg.Sopcinfo == "" {
+		return cfg.Window, fmt.Errorf("region %q requires a sopcinfo file", cfg.Region)
+	}
+	sys, err := sopc.Load(cfg.Sopcinfo)
+	if err != nil {
+		return cfg.Window, err
+	}
+	return WindowFromRegion(sys, cfg.Region, cfg.Window)
+}
@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileSource воспроизводит ранее сохранённые отсчёты из текстового файла.
+//
+// Поддерживаются файлы с одним значением в строке и CSV, записанные
+// storage.SaveSample («время,значение») — берётся последнее поле строки.
+type FileSource struct {
+	path       string
+	frameSize  int
+	sampleRate float64
+	loop       bool
+	file       *os.File
+	scanner    *bufio.Scanner
+}
+
+// NewFileSource создаёт источник воспроизведения файла.
+// При sampleRate > 0 кадры выдаются в темпе исходной частоты дискретизации.
+func NewFileSource(path string, frameSize int, sampleRate float64, loop bool) *FileSource {
+	return &FileSource{path: path, frameSize: frameSize, sampleRate: sampleRate, loop: loop}
+}
+
+func (s *FileSource) Open(ctx context.Context) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("open %s failed: %w", s.path, err)
+	}
+	s.file = file
+	s.scanner = bufio.NewScanner(file)
+	return nil
+}
+
+func (s *FileSource) Next(ctx context.Context, frame *Frame) error {
+	if s.file == nil {
+		return fmt.Errorf("file source %s is not open", s.path)
+	}
+	if err := wait(ctx, frameDuration(s.frameSize, s.sampleRate)); err != nil {
+		return err
+	}
+
+	frame.Samples = frame.Samples[:0]
+	frame.Unit = UnitADCVolts
+	rewound := false
+	for len(frame.Samples) < s.frameSize {
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				return fmt.Errorf("read %s failed: %w", s.path, err)
+			}
+			if len(frame.Samples) > 0 {
+				break
+			}
+			if !s.loop || rewound {
+				return io.EOF
+			}
+			if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("rewind %s failed: %w", s.path, err)
+			}
+			s.scanner = bufio.NewScanner(s.file)
+			rewound = true
+			continue
+		}
+
+		val, ok := parseSampleLine(s.scanner.Text())
+		if ok {
+			frame.Samples = append(frame.Samples, val)
+		}
+	}
+	return nil
+}
+
+func (s *FileSource) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// parseSampleLine извлекает значение отсчёта из строки файла.
+func parseSampleLine(line string) (float64, bool) {
+	if i := strings.LastIndexByte(line, ','); i >= 0 {
+		line = line[i+1:]
+	}
+	val, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+	if err != nil {
+		return 0, false
+	}
+	return val, true
+}
+
+// frameDuration возвращает длительность кадра из n отсчётов.
+func frameDuration(n int, sampleRate float64) time.Duration {
+	if sampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / sampleRate * float64(time.Second))
+}
+
+// wait ждёт d либо отмены контекста.
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,413 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Формат файла сессии (все числа little-endian):
+//
+//	magic   [4]byte "USES"
+//	version uint16
+//	_       uint16
+//	infoLen uint32
+//	info    [infoLen]byte — SessionInfo в JSON
+//
+// затем записи кадров:
+//
+//	seq       uint64
+//	timestamp int64  — Unix-время захвата, нс
+//	counter   uint32 — аппаратный счётчик кадров
+//	flags     uint32 — бит 0: счётчик есть, бит 1: повторное чтение
+//	dropped   uint64
+//	n         uint32
+//	codes     [n]uint16 — сырые коды АЦП в формате окна
+const (
+	sessionMagic   = "USES"
+	SessionVersion = 1
+
+	sessionHeaderLen = 12
+	recordHeaderLen  = 36
+	maxInfoLen       = 1 << 20 // заголовок длиннее — признак повреждённого файла
+
+	recordHasCounter = 1 << 0
+	recordRepeated   = 1 << 1
+)
+
+// SessionInfo — заголовок записи: откуда и с какими настройками получены кадры.
+type SessionInfo struct {
+	Version  int       `json:"version"`
+	SourceID string    `json:"source_id"`
+	Started  time.Time `json:"started"`
+	Config   Config    `json:"config"` // конфигурация захвата, окно уже разрешено
+}
+
+// Recorder пишет кадры в компактный двоичный файл сессии.
+//
+// Хранятся коды АЦП, а не вольты: отсчёты переводятся обратно через
+// Decoder.Encode, что для кадров с устройства даёт исходные слова без потерь.
+type Recorder struct {
+	path    string
+	file    *os.File
+	w       *bufio.Writer
+	decoder *Decoder
+	codes   []uint16
+	buf     []byte
+	frames  uint64
+}
+
+// NewRecorder создаёт файл сессии и записывает в него заголовок.
+func NewRecorder(path, sourceID string, cfg Config) (*Recorder, error) {
+	window, err := resolveWindow(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := window.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.Window = window
+	decoder, err := NewDecoder(window.Encoding, window.FullScaleVolts)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := json.Marshal(SessionInfo{
+		Version:  SessionVersion,
+		SourceID: sourceID,
+		Started:  time.Now(),
+		Config:   cfg,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode session info failed: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create %s failed: %w", path, err)
+	}
+	r := &Recorder{path: path, file: file, w: bufio.NewWriter(file), decoder: decoder}
+
+	var header [sessionHeaderLen]byte
+	copy(header[:], sessionMagic)
+	binary.LittleEndian.PutUint16(header[4:], SessionVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(info)))
+	r.w.Write(header[:])
+	if _, err := r.w.Write(info); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("write %s failed: %w", path, err)
+	}
+	return r, nil
+}
+
+// Write добавляет кадр в сессию. Записываются только некалиброванные кадры
+// источника (UnitADCVolts), чтобы коды восстанавливались без потерь.
+func (r *Recorder) Write(frame *Frame) error {
+	if frame.Unit != UnitADCVolts {
+		return fmt.Errorf("session records %s samples, got %q", UnitADCVolts, frame.Unit)
+	}
+	r.codes = r.codes[:0]
+	for _, v := range frame.Samples {
+		r.codes = append(r.codes, r.decoder.Encode(v))
+	}
+
+	var flags uint32
+	if frame.HasCounter {
+		flags |= recordHasCounter
+	}
+	if frame.Repeated {
+		flags |= recordRepeated
+	}
+
+	size := recordHeaderLen + 2*len(r.codes)
+	if cap(r.buf) < size {
+		r.buf = make([]byte, size)
+	}
+	buf := r.buf[:size]
+	binary.LittleEndian.PutUint64(buf[0:], frame.Seq)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(frame.Timestamp.UnixNano()))
+	binary.LittleEndian.PutUint32(buf[16:], frame.Counter)
+	binary.LittleEndian.PutUint32(buf[20:], flags)
+	binary.LittleEndian.PutUint64(buf[24:], frame.Dropped)
+	binary.LittleEndian.PutUint32(buf[32:], uint32(len(r.codes)))
+	for i, code := range r.codes {
+		binary.LittleEndian.PutUint16(buf[recordHeaderLen+2*i:], code)
+	}
+
+	if _, err := r.w.Write(buf); err != nil {
+		return fmt.Errorf("write %s failed: %w", r.path, err)
+	}
+	r.frames++
+	return nil
+}
+
+// Frames возвращает число записанных кадров.
+func (r *Recorder) Frames() uint64 {
+	return r.frames
+}
+
+// Flush сбрасывает накопленные кадры в файл.
+func (r *Recorder) Flush() error {
+	if err := r.w.Flush(); err != nil {
+		return fmt.Errorf("write %s failed: %w", r.path, err)
+	}
+	return nil
+}
+
+// Close сбрасывает буфер и закрывает файл.
+func (r *Recorder) Close() error {
+	if err := r.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// SessionReader последовательно читает кадры из файла сессии.
+type SessionReader struct {
+	path    string
+	file    *os.File
+	r       *bufio.Reader
+	info    SessionInfo
+	decoder *Decoder
+	data    int64 // смещение первой записи кадра
+	header  [recordHeaderLen]byte
+	raw     []byte
+}
+
+// OpenSession открывает файл сессии и читает его заголовок.
+func OpenSession(path string) (*SessionReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s failed: %w", path, err)
+	}
+	s := &SessionReader{path: path, file: file, r: bufio.NewReader(file)}
+	if err := s.readInfo(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read session %s failed: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *SessionReader) readInfo() error {
+	var header [sessionHeaderLen]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:4], []byte(sessionMagic)) {
+		return errors.New("not a session file")
+	}
+	if version := binary.LittleEndian.Uint16(header[4:]); version != SessionVersion {
+		return fmt.Errorf("unsupported session version %d", version)
+	}
+
+	infoLen := binary.LittleEndian.Uint32(header[8:])
+	if infoLen > maxInfoLen {
+		return fmt.Errorf("session info of %d bytes exceeds %d bytes", infoLen, maxInfoLen)
+	}
+	info := make([]byte, infoLen)
+	if _, err := io.ReadFull(s.r, info); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(info, &s.info); err != nil {
+		return fmt.Errorf("decode session info failed: %w", err)
+	}
+
+	window := s.info.Config.Window
+	if err := window.Validate(); err != nil {
+		return err
+	}
+	decoder, err := NewDecoder(window.Encoding, window.FullScaleVolts)
+	if err != nil {
+		return err
+	}
+	s.decoder = decoder
+	s.data = int64(sessionHeaderLen + len(info))
+	return nil
+}
+
+// Info возвращает заголовок сессии.
+func (s *SessionReader) Info() SessionInfo {
+	return s.info
+}
+
+// Next читает очередной кадр, переводя коды в вольты. В конце файла возвращает io.EOF.
+// Недописанная последняя запись (процесс записи был прерван) тоже считается концом сессии.
+// Запись длиннее кадра из заголовка сессии считается повреждённой.
+func (s *SessionReader) Next(frame *Frame) error {
+	if _, err := io.ReadFull(s.r, s.header[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF
+		}
+		return fmt.Errorf("read %s failed: %w", s.path, err)
+	}
+	h := s.header[:]
+	flags := binary.LittleEndian.Uint32(h[20:])
+	n := int(binary.LittleEndian.Uint32(h[32:]))
+	if n > s.info.Config.Window.FrameSize {
+		return fmt.Errorf("read %s failed: record of %d samples exceeds the frame size %d", s.path, n, s.info.Config.Window.FrameSize)
+	}
+
+	if cap(s.raw) < 2*n {
+		s.raw = make([]byte, 2*n)
+	}
+	raw := s.raw[:2*n]
+	if _, err := io.ReadFull(s.r, raw); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF
+		}
+		return fmt.Errorf("read %s failed: %w", s.path, err)
+	}
+
+	frame.Seq = binary.LittleEndian.Uint64(h[0:])
+	frame.Timestamp = time.Unix(0, int64(binary.LittleEndian.Uint64(h[8:])))
+	frame.SourceID = s.info.SourceID
+	frame.Counter = binary.LittleEndian.Uint32(h[16:])
+	frame.HasCounter = flags&recordHasCounter != 0
+	frame.Repeated = flags&recordRepeated != 0
+	frame.Dropped = binary.LittleEndian.Uint64(h[24:])
+
+	frame.Samples = frame.Samples[:0]
+	frame.Unit = UnitADCVolts
+	for i := 0; i < n; i++ {
+		frame.Samples = append(frame.Samples, s.decoder.Volts(binary.LittleEndian.Uint16(raw[2*i:])))
+	}
+	return nil
+}
+
+// Rewind возвращает чтение к первому кадру.
+func (s *SessionReader) Rewind() error {
+	if _, err := s.file.Seek(s.data, io.SeekStart); err != nil {
+		return fmt.Errorf("seek %s failed: %w", s.path, err)
+	}
+	s.r.Reset(s.file)
+	return nil
+}
+
+// Close закрывает файл.
+func (s *SessionReader) Close() error {
+	return s.file.Close()
+}
+
+// ReplayMode — темп воспроизведения сессии.
+type ReplayMode string
+
+const (
+	// ReplayRealtime выдаёт кадры с исходными интервалами между ними.
+	ReplayRealtime ReplayMode = "realtime"
+	// ReplayFast выдаёт кадры без задержек.
+	ReplayFast ReplayMode = "fast"
+	// ReplayStep выдаёт по одному кадру на каждый сигнал канала шагов.
+	ReplayStep ReplayMode = "step"
+)
+
+// SessionSource воспроизводит записанную сессию как источник кадров.
+//
+// Момент захвата берётся из записи, поэтому Tracker сохраняет исходные
+// метки времени, а по записанным счётчикам заново находит те же пропуски.
+// При воспроизведении по кругу Tracker узнаёт о перемотке через Rewound
+// и не считает скачок счётчика к началу записи пропуском кадров.
+type SessionSource struct {
+	path     string
+	mode     ReplayMode
+	step     <-chan struct{}
+	loop     bool
+	reader   *SessionReader
+	last     time.Time // время захвата предыдущего выданного кадра
+	captured time.Time
+	rewound  bool // последний кадр выдан после перемотки к началу записи
+}
+
+// NewSessionSource создаёт источник воспроизведения сессии.
+// В режиме ReplayStep каждый кадр выдаётся после сигнала из step.
+func NewSessionSource(path string, mode ReplayMode, step <-chan struct{}, loop bool) *SessionSource {
+	return &SessionSource{path: path, mode: mode, step: step, loop: loop}
+}
+
+func (s *SessionSource) Open(ctx context.Context) error {
+	switch s.mode {
+	case ReplayRealtime, ReplayFast:
+	case ReplayStep:
+		if s.step == nil {
+			return errors.New("step replay requires a step channel")
+		}
+	default:
+		return fmt.Errorf("unknown replay mode %q", s.mode)
+	}
+	reader, err := OpenSession(s.path)
+	if err != nil {
+		return err
+	}
+	s.reader = reader
+	s.last = time.Time{}
+	return nil
+}
+
+// Info возвращает заголовок открытой сессии.
+func (s *SessionSource) Info() SessionInfo {
+	return s.reader.Info()
+}
+
+func (s *SessionSource) Next(ctx context.Context, frame *Frame) error {
+	if s.reader == nil {
+		return fmt.Errorf("session source %s is not open", s.path)
+	}
+
+	if s.mode == ReplayStep {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-s.step:
+			if !ok {
+				return io.EOF
+			}
+		}
+	}
+
+	s.rewound = false
+	err := s.reader.Next(frame)
+	if errors.Is(err, io.EOF) && s.loop {
+		if err := s.reader.Rewind(); err != nil {
+			return err
+		}
+		s.last = time.Time{}
+		s.rewound = true
+		err = s.reader.Next(frame)
+	}
+	if err != nil {
+		return err
+	}
+
+	if s.mode == ReplayRealtime && !s.last.IsZero() {
+		if err := wait(ctx, frame.Timestamp.Sub(s.last)); err != nil {
+			return err
+		}
+	}
+	s.last = frame.Timestamp
+	s.captured = frame.Timestamp
+	return nil
+}
+
+// CapturedAt возвращает исходное время захвата последнего выданного кадра.
+func (s *SessionSource) CapturedAt() time.Time {
+	return s.captured
+}
+
+// Rewound сообщает, что последний кадр выдан после перемотки к началу записи.
+func (s *SessionSource) Rewound() bool {
+	return s.rewound
+}
+
+func (s *SessionSource) Close() error {
+	if s.reader == nil {
+		return nil
+	}
+	return s.reader.Close()
+}
@@ -0,0 +1,217 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// recordSession записывает n кадров модели в файл и возвращает их копии.
+func recordSession(t *testing.T, n int) (string, []Frame) {
+	t.Helper()
+	cfg := Config{Source: SourceSynthetic, Window: DefaultWindow(), Sim: DefaultSimConfig()}
+	cfg.Window.FrameSize = 700
+	cfg.Sim.Seed = 3
+	cfg.Sim.DropProbability = 0.2
+
+	source, err := NewSource(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := NewTracker(source, "sim")
+	if err := tracker.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Close()
+
+	path := filepath.Join(t.TempDir(), "session.bin")
+	recorder, err := NewRecorder(path, "sim", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frames []Frame
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < n; i++ {
+		var frame Frame
+		if err := source.Next(context.Background(), &frame); err != nil {
+			t.Fatal(err)
+		}
+		tracker.Stamp(&frame, start.Add(time.Duration(i)*20*time.Millisecond))
+		if err := recorder.Write(&frame); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, frame)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path, frames
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+	path, want := recordSession(t, 5)
+
+	source := NewSessionSource(path, ReplayFast, nil, false)
+	tracker := NewTracker(source, "replay")
+	if err := tracker.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Close()
+
+	if info := source.Info(); info.SourceID != "sim" || info.Config.Window.FrameSize != 700 {
+		t.Fatalf("session info = %+v", info)
+	}
+
+	var frame Frame
+	for i, w := range want {
+		if err := tracker.Next(context.Background(), &frame); err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if frame.Seq != w.Seq || frame.Counter != w.Counter || frame.Dropped != w.Dropped || !frame.Timestamp.Equal(w.Timestamp) {
+			t.Fatalf("frame %d header = %d/%d/%d/%v, want %d/%d/%d/%v", i,
+				frame.Seq, frame.Counter, frame.Dropped, frame.Timestamp, w.Seq, w.Counter, w.Dropped, w.Timestamp)
+		}
+		if len(frame.Samples) != len(w.Samples) {
+			t.Fatalf("frame %d has %d samples, want %d", i, len(frame.Samples), len(w.Samples))
+		}
+		for j := range w.Samples {
+			if frame.Samples[j] != w.Samples[j] {
+				t.Fatalf("frame %d sample %d = %g, want %g", i, j, frame.Samples[j], w.Samples[j])
+			}
+		}
+	}
+	if err := tracker.Next(context.Background(), &frame); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next after the last frame = %v, want io.EOF", err)
+	}
+}
+
+func TestSessionLoopKeepsDrops(t *testing.T) {
+	path, want := recordSession(t, 20)
+	var recorded uint64
+	for _, w := range want {
+		recorded += w.Dropped
+	}
+	if recorded == 0 {
+		t.Fatal("recording has no dropped frames")
+	}
+
+	source := NewSessionSource(path, ReplayFast, nil, true)
+	tracker := NewTracker(source, "replay")
+	if err := tracker.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.Close()
+
+	var frame Frame
+	for pass := 1; pass <= 3; pass++ {
+		for i, w := range want {
+			if err := tracker.Next(context.Background(), &frame); err != nil {
+				t.Fatalf("pass %d frame %d: %v", pass, i, err)
+			}
+			if frame.Dropped != w.Dropped {
+				t.Fatalf("pass %d frame %d dropped %d, want %d", pass, i, frame.Dropped, w.Dropped)
+			}
+		}
+		if got := tracker.Stats().Dropped; got != uint64(pass)*recorded {
+			t.Fatalf("after pass %d: %d dropped, want %d", pass, got, uint64(pass)*recorded)
+		}
+	}
+}
+
+func TestSessionRejectsOversizedRecord(t *testing.T) {
+	path, _ := recordSession(t, 1)
+	reader, err := OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := reader.data + recordHeaderLen - 4 // поле n первой записи
+	reader.Close()
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, offset); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	reader, err = OpenSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var frame Frame
+	if err := reader.Next(&frame); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("Next on a corrupt record = %v, want an error", err)
+	}
+}
+
+func TestSessionRejectsOversizedInfo(t *testing.T) {
+	path, _ := recordSession(t, 1)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, 8); err != nil { // поле infoLen
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if reader, err := OpenSession(path); err == nil {
+		reader.Close()
+		t.Fatal("OpenSession accepted a 4 GiB session info")
+	}
+}
+
+func TestSessionStepReplay(t *testing.T) {
+	path, _ := recordSession(t, 2)
+
+	step := make(chan struct{})
+	source := NewSessionSource(path, ReplayStep, step, false)
+	if err := source.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var frame Frame
+	if err := source.Next(ctx, &frame); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Next without a step = %v, want deadline exceeded", err)
+	}
+
+	go func() { step <- struct{}{} }()
+	if err := source.Next(context.Background(), &frame); err != nil {
+		t.Fatalf("Next after a step: %v", err)
+	}
+	if frame.Seq != 1 {
+		t.Fatalf("first stepped frame has seq %d, want 1", frame.Seq)
+	}
+}
+
+func TestSessionRealtimeReplay(t *testing.T) {
+	path, want := recordSession(t, 3)
+
+	source := NewSessionSource(path, ReplayRealtime, nil, false)
+	if err := source.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	began := time.Now()
+	var frame Frame
+	for range want {
+		if err := source.Next(context.Background(), &frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed, span := time.Since(began), want[2].Timestamp.Sub(want[0].Timestamp); elapsed < span {
+		t.Fatalf("realtime replay took %v, recording spans %v", elapsed, span)
+	}
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Echo — отражённый импульс в моделируемом А-скане.
+type Echo struct {
+	Delay         float64 // задержка относительно зондирующего импульса, с
+	Amplitude     float64 // коэффициент отражения относительно зондирующего импульса
+	AttenuationDB float64 // затухание на пути распространения, дБ
+}
+
+// SimConfig — параметры моделируемого А-скана.
+type SimConfig struct {
+	ADCRateHz       float64 // частота дискретизации АЦП, Гц
+	BurstFreqHz     float64 // несущая зондирующего импульса, Гц
+	BurstCycles     float64 // число периодов в импульсе
+	BurstAmplitude  float64 // амплитуда зондирующего импульса, В
+	TriggerDelay    float64 // положение зондирующего импульса от начала кадра, с
+	Echoes          []Echo
+	NoiseRMS        float64 // среднеквадратичное значение аддитивного шума, В
+	ADCBits         int     // эффективная разрядность АЦП (0 — разрядность формата)
+	DropProbability float64 // вероятность пропуска кадра FPGA, для проверки учёта потерь
+	Seed            int64   // 0 — случайное зерно
+}
+
+// DefaultSimConfig возвращает А-скан при частоте дискретизации обработки
+// по умолчанию (1 МГц): импульс на 5 мкс и два донных эха через 300 и 600 мкс,
+// оба внутри кадра из DefaultFrameSize отсчётов. Первое эхо — полное
+// отражение, чтобы оно превышало порог обнаружения настроек по умолчанию.
+func DefaultSimConfig() SimConfig {
+	return SimConfig{
+		ADCRateHz:      1e6,
+		BurstFreqHz:    100e3,
+		BurstCycles:    3,
+		BurstAmplitude: 0.4,
+		TriggerDelay:   5e-6,
+		Echoes: []Echo{
+			{Delay: 300e-6, Amplitude: 1},
+			{Delay: 600e-6, Amplitude: 1, AttenuationDB: 6},
+		},
+		NoiseRMS: 0.005,
+		ADCBits:  12,
+	}
+}
+
+// Validate проверяет параметры модели.
+func (c SimConfig) Validate() error {
+	if c.ADCRateHz <= 0 {
+		return fmt.Errorf("invalid simulator ADC rate %g Hz", c.ADCRateHz)
+	}
+	if c.BurstFreqHz <= 0 || c.BurstFreqHz >= c.ADCRateHz/2 {
+		return fmt.Errorf("simulator burst frequency %g Hz must be within (0, %g)", c.BurstFreqHz, c.ADCRateHz/2)
+	}
+	if c.BurstCycles <= 0 {
+		return fmt.Errorf("invalid simulator burst cycles %g", c.BurstCycles)
+	}
+	if c.ADCBits < 0 || c.ADCBits > 16 {
+		return fmt.Errorf("invalid simulator ADC bits %d", c.ADCBits)
+	}
+	if c.DropProbability < 0 || c.DropProbability >= 1 {
+		return fmt.Errorf("invalid simulator drop probability %g", c.DropProbability)
+	}
+	for i, e := range c.Echoes {
+		if e.Delay < 0 {
+			return fmt.Errorf("echo %d has negative delay", i)
+		}
+	}
+	return nil
+}
+
+// SimulatedSource моделирует приёмный тракт без платы и работает на любой ОС.
+//
+// Кадр складывается из зондирующего импульса (синус с окном Ханна),
+// эхо той же формы с задержкой, коэффициентом отражения и затуханием,
+// гауссова шума и модели АЦП: квантование до ADCBits разрядов, насыщение
+// и перевод в формат окна тем же декодером, что и для реального устройства.
+type SimulatedSource struct {
+	cfg        SimConfig
+	window     Window
+	frameRate  float64
+	decoder    *Decoder
+	rng        *rand.Rand
+	clean      []float64
+	counter    uint32
+	quantLevel float64
+}
+
+// NewSimulatedSource создаёт модель с кадрами в формате window.
+// Кадры выдаются в темпе frameSize/sampleRate, как у остальных источников.
+func NewSimulatedSource(cfg SimConfig, window Window, sampleRate float64) *SimulatedSource {
+	return &SimulatedSource{cfg: cfg, window: window, frameRate: sampleRate}
+}
+
+func (s *SimulatedSource) Open(ctx context.Context) error {
+	if err := s.cfg.Validate(); err != nil {
+		return err
+	}
+	decoder, err := NewDecoder(s.window.Encoding, s.window.FullScaleVolts)
+	if err != nil {
+		return err
+	}
+
+	seed := s.cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	s.decoder = decoder
+	s.rng = rand.New(rand.NewSource(seed))
+	s.clean = s.render()
+	s.counter = 0
+	s.quantLevel = 0
+	if s.cfg.ADCBits > 0 {
+		s.quantLevel = s.window.FullScaleVolts / float64(uint(1)<<s.cfg.ADCBits)
+	}
+	return nil
+}
+
+func (s *SimulatedSource) Next(ctx context.Context, frame *Frame) error {
+	if s.decoder == nil {
+		return fmt.Errorf("simulated source is not open")
+	}
+	if err := wait(ctx, frameDuration(s.window.FrameSize, s.frameRate)); err != nil {
+		return err
+	}
+
+	// Однополярный АЦП видит сигнал относительно середины шкалы.
+	bias := 0.0
+	if !s.decoder.Signed() {
+		bias = s.window.FullScaleVolts / 2
+	}
+
+	frame.Samples = frame.Samples[:0]
+	frame.Unit = UnitADCVolts
+	for _, v := range s.clean {
+		v += bias + s.rng.NormFloat64()*s.cfg.NoiseRMS
+		if s.quantLevel > 0 {
+			v = math.Round(v/s.quantLevel) * s.quantLevel
+		}
+		frame.Samples = append(frame.Samples, s.decoder.Volts(s.decoder.Encode(v)))
+	}
+
+	s.counter++
+	for s.cfg.DropProbability > 0 && s.rng.Float64() < s.cfg.DropProbability {
+		s.counter++
+	}
+	frame.Counter = s.counter
+	frame.HasCounter = true
+	return nil
+}
+
+func (s *SimulatedSource) Close() error {
+	return nil
+}
+
+// render строит кадр без шума: зондирующий импульс и эхо.
+func (s *SimulatedSource) render() []float64 {
+	out := make([]float64, s.window.FrameSize)
+	s.addPulse(out, s.cfg.TriggerDelay, s.cfg.BurstAmplitude)
+	for _, e := range s.cfg.Echoes {
+		amp := s.cfg.BurstAmplitude * e.Amplitude * math.Pow(10, -e.AttenuationDB/20)
+		s.addPulse(out, s.cfg.TriggerDelay+e.Delay, amp)
+	}
+	return out
+}
+
+// addPulse добавляет импульс из BurstCycles периодов с окном Ханна, начинающийся в момент start.
+func (s *SimulatedSource) addPulse(out []float64, start, amplitude float64) {
+	duration := s.cfg.BurstCycles / s.cfg.BurstFreqHz
+	first := int(math.Ceil(start * s.cfg.ADCRateHz))
+	last := int(math.Floor((start + duration) * s.cfg.ADCRateHz))
+	for i := max(first, 0); i <= last && i < len(out); i++ {
+		t := float64(i)/s.cfg.ADCRateHz - start
+		hann := 0.5 - 0.5*math.Cos(2*math.Pi*t/duration)
+		out[i] += amplitude * hann * math.Sin(2*math.Pi*s.cfg.BurstFreqHz*t)
+	}
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func openSim(t *testing.T, cfg SimConfig, w Window) *SimulatedSource {
+	t.Helper()
+	src := NewSimulatedSource(cfg, w, 0)
+	if err := src.Open(context.Background()); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { src.Close() })
+	return src
+}
+
+func TestSimulatedSourceEchoes(t *testing.T) {
+	cfg := DefaultSimConfig()
+	cfg.NoiseRMS = 0
+	cfg.Seed = 1
+	w := DefaultWindow()
+	w.Encoding = EncodingS16
+	src := openSim(t, cfg, w)
+
+	var frame Frame
+	if err := src.Next(context.Background(), &frame); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if len(frame.Samples) != w.FrameSize {
+		t.Fatalf("frame has %d samples, want %d", len(frame.Samples), w.FrameSize)
+	}
+
+	// Пик каждого импульса — в середине окна Ханна.
+	half := cfg.BurstCycles / cfg.BurstFreqHz / 2
+	peak := func(at float64) float64 {
+		from := max(int((at-half)*cfg.ADCRateHz), 0)
+		to := int((at + 3*half) * cfg.ADCRateHz)
+		p := 0.0
+		for _, v := range frame.Samples[from:to] {
+			p = math.Max(p, math.Abs(v))
+		}
+		return p
+	}
+	bang := peak(cfg.TriggerDelay)
+	first := peak(cfg.TriggerDelay + cfg.Echoes[0].Delay)
+	second := peak(cfg.TriggerDelay + cfg.Echoes[1].Delay)
+
+	if math.Abs(first/bang-cfg.Echoes[0].Amplitude) > 0.05 {
+		t.Fatalf("first echo ratio = %.3f, want %.3f", first/bang, cfg.Echoes[0].Amplitude)
+	}
+	// 6 дБ затухания — примерно половина амплитуды первого эха.
+	if math.Abs(second/first-0.5) > 0.05 {
+		t.Fatalf("second/first echo ratio = %.3f, want ~0.5", second/first)
+	}
+	if frame.Samples[0] != 0 {
+		t.Fatalf("noise-free sample before the burst = %g, want 0", frame.Samples[0])
+	}
+}
+
+func TestSimulatedSourceQuantization(t *testing.T) {
+	cfg := DefaultSimConfig()
+	cfg.ADCBits = 8
+	cfg.Seed = 1
+	w := DefaultWindow()
+	src := openSim(t, cfg, w)
+
+	var frame Frame
+	if err := src.Next(context.Background(), &frame); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	lsb := w.FullScaleVolts / 256
+	for i, v := range frame.Samples {
+		if v < 0 || v >= w.FullScaleVolts {
+			t.Fatalf("sample %d = %g is outside the unsigned ADC range", i, v)
+		}
+		if steps := v / lsb; math.Abs(steps-math.Round(steps)) > 1e-6 {
+			t.Fatalf("sample %d = %g is not a multiple of the %d-bit LSB", i, v, cfg.ADCBits)
+		}
+	}
+}
+
+func TestSimulatedSourceDrops(t *testing.T) {
+	cfg := DefaultSimConfig()
+	cfg.DropProbability = 0.3
+	cfg.Seed = 1
+	tracker := NewTracker(openSim(t, cfg, DefaultWindow()), SourceSynthetic)
+
+	var frame Frame
+	for i := 0; i < 200; i++ {
+		if err := tracker.Next(context.Background(), &frame); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+	stats := tracker.Stats()
+	if stats.Dropped == 0 {
+		t.Fatal("tracker did not see simulated drops")
+	}
+	if uint64(frame.Counter) != stats.Frames+stats.Dropped {
+		t.Fatalf("counter %d != frames %d + dropped %d", frame.Counter, stats.Frames, stats.Dropped)
+	}
+}
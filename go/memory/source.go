@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Имена источников кадров, которые принимает NewSource.
+const (
+	SourceDevMem    = "devmem"
+	SourceUIO       = "uio"
+	SourceFile      = "file"
+	SourceSynthetic = "synthetic"
+	SourceSession   = "session"
+)
+
+// Frame — один кадр, полученный от источника данных.
+type Frame struct {
+	Seq          uint64    // монотонный номер кадра в сессии, начиная с 1
+	Timestamp    time.Time // момент захвата кадра
+	SourceID     string    // идентификатор источника
+	Counter      uint32    // аппаратный счётчик записанных кадров из заголовка окна
+	HasCounter   bool      // источник предоставляет аппаратный счётчик
+	Dropped      uint64    // кадры, потерянные между предыдущим и этим кадром
+	Repeated     bool      // кадр уже был прочитан (счётчик не изменился)
+	CounterReset bool      // счётчик уменьшился (FPGA перезапущена), пропуски не считались
+	Unit         Unit      // единица отсчётов
+	Samples      []float64
+}
+
+// FrameSource — источник кадров АЦП.
+//
+// Open подготавливает устройство или файл, Next заполняет очередной кадр
+// (буфер frame.Samples переиспользуется), Close освобождает ресурсы.
+// Next возвращает io.EOF, когда источник исчерпан.
+type FrameSource interface {
+	Open(ctx context.Context) error
+	Next(ctx context.Context, frame *Frame) error
+	Close() error
+}
+
+// Config описывает, откуда и как читать кадры.
+type Config struct {
+	Source       string    // devmem, uio, file, synthetic или session
+	Path         string    // устройство (/dev/mem, /dev/uio0) или файл записи
+	UIOMap       int       // номер области mapN устройства UIO
+	Window       Window    // окно захвата: адрес, длина кадра, формат отсчётов
+	Sopcinfo     string    // файл .sopcinfo с картой памяти Qsys
+	Region       string    // ведомый интерфейс, задающий адрес окна, например onchip_memory2_0.s1
+	SampleRateHz float64   // частота выдачи отсчётов для file и synthetic
+	Loop         bool      // воспроизводить файл по кругу
+	Sim          SimConfig // модель А-скана для synthetic
+
+	// Готовность кадра для devmem и uio: прерывание UIO (IRQPath) или
+	// опрос с периодом PollInterval. Без них кадры читаются подряд.
+	IRQPath      string        // устройство UIO, чьё прерывание сообщает о новом кадре
+	IRQTimeout   time.Duration // предельное ожидание прерывания (0 — без ограничения)
+	PollInterval time.Duration // период опроса без прерываний
+
+	Replay ReplayMode      `json:"-"` // темп воспроизведения session
+	Step   <-chan struct{} `json:"-"` // сигналы шагов для ReplayStep
+}
+
+// NewSource создаёт источник кадров по конфигурации.
+// Выбор делается во время выполнения, поэтому один и тот же бинарный файл
+// работает и на плате, и на рабочей машине.
+func NewSource(cfg Config) (FrameSource, error) {
+	window, err := resolveWindow(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := window.Validate(); err != nil {
+		return nil, err
+	}
+	cfg.Window = window
+
+	switch cfg.Source {
+	case SourceDevMem, "":
+		return withWaiter(cfg, NewDevMemSource(cfg.Path, cfg.Window)), nil
+	case SourceUIO:
+		return withWaiter(cfg, NewUIOSource(cfg.Path, cfg.UIOMap, cfg.Window)), nil
+	case SourceFile:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("source %q requires a path", cfg.Source)
+		}
+		return NewFileSource(cfg.Path, cfg.Window.FrameSize, cfg.SampleRateHz, cfg.Loop), nil
+	case SourceSynthetic:
+		return NewSimulatedSource(cfg.Sim, cfg.Window, cfg.SampleRateHz), nil
+	case SourceSession:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("source %q requires a path", cfg.Source)
+		}
+		mode := cfg.Replay
+		if mode == "" {
+			mode = ReplayRealtime
+		}
+		return NewSessionSource(cfg.Path, mode, cfg.Step, cfg.Loop), nil
+	default:
+		return nil, fmt.Errorf("unknown frame source %q", cfg.Source)
+	}
+}
+
+// withWaiter добавляет к источнику ожидание готовности кадра, если оно задано.
+func withWaiter(cfg Config, source FrameSource) FrameSource {
+	switch {
+	case cfg.IRQPath != "":
+		return NewWaitingSource(source, NewUIOWaiter(cfg.IRQPath, cfg.IRQTimeout))
+	case cfg.PollInterval > 0:
+		return NewWaitingSource(source, NewPollWaiter(cfg.PollInterval))
+	default:
+		return source
+	}
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FrameStats — счётчики кадров за сессию.
+type FrameStats struct {
+	Frames   uint64 // выданные кадры
+	Dropped  uint64 // кадры, перезаписанные FPGA до того, как их успели прочитать
+	Repeated uint64 // повторные чтения кадра, который FPGA ещё не обновила
+	Resets   uint64 // сбросы аппаратного счётчика (перезагрузка FPGA)
+}
+
+// Tracker нумерует кадры источника, ставит метку времени захвата и по
+// аппаратному счётчику из заголовка окна обнаруживает пропуски.
+//
+// Если счётчик вырос больше чем на единицу, промежуточные кадры были
+// перезаписаны; если не изменился — кадр прочитан повторно. Скачок на
+// counterResetGap и больше (по модулю 2³²) — это уменьшение счётчика,
+// то есть его сброс: отсчёт начинается заново без учёта пропусков.
+// Без аппаратного счётчика пропуски не обнаруживаются.
+type Tracker struct {
+	source   FrameSource
+	sourceID string
+
+	mu          sync.Mutex
+	seq         uint64
+	lastCounter uint32
+	hasLast     bool
+	stats       FrameStats
+}
+
+// counterResetGap — наименьший прирост счётчика, который считается его сбросом.
+// Столько кадров между двумя чтениями не теряется: при 10 кГц это почти
+// пятьдесят дней.
+const counterResetGap = 1 << 31
+
+// NewTracker оборачивает источник; sourceID попадает в каждый кадр.
+func NewTracker(source FrameSource, sourceID string) *Tracker {
+	return &Tracker{source: source, sourceID: sourceID}
+}
+
+func (t *Tracker) Open(ctx context.Context) error {
+	return t.source.Open(ctx)
+}
+
+// capturer — источник, который сам знает момент захвата кадра (например, запись сессии).
+type capturer interface {
+	CapturedAt() time.Time
+}
+
+// rewinder — источник, который может начать выдачу заново (запись сессии по кругу).
+// После перемотки аппаратный счётчик возвращается к началу записи, и разность
+// с последним кадром предыдущего прохода не означает пропусков.
+type rewinder interface {
+	Rewound() bool
+}
+
+func (t *Tracker) Next(ctx context.Context, frame *Frame) error {
+	if err := t.source.Next(ctx, frame); err != nil {
+		return err
+	}
+	captured := time.Now()
+	if c, ok := t.source.(capturer); ok {
+		captured = c.CapturedAt()
+	}
+	if r, ok := t.source.(rewinder); ok && r.Rewound() {
+		t.mu.Lock()
+		t.hasLast = false
+		t.mu.Unlock()
+	}
+	t.Stamp(frame, captured)
+	return nil
+}
+
+func (t *Tracker) Close() error {
+	return t.source.Close()
+}
+
+// Stamp присваивает кадру номер, время захвата и идентификатор источника
+// и обновляет счётчики пропусков.
+func (t *Tracker) Stamp(frame *Frame, captured time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seq++
+	t.stats.Frames++
+	frame.Seq = t.seq
+	frame.Timestamp = captured
+	frame.SourceID = t.sourceID
+	frame.Dropped = 0
+	frame.Repeated = false
+	frame.CounterReset = false
+
+	if !frame.HasCounter {
+		return
+	}
+	if t.hasLast {
+		// Разность uint32 корректна и при переполнении счётчика.
+		switch gap := frame.Counter - t.lastCounter; {
+		case gap == 0:
+			frame.Repeated = true
+			t.stats.Repeated++
+		case gap >= counterResetGap:
+			frame.CounterReset = true
+			t.stats.Resets++
+		case gap > 1:
+			frame.Dropped = uint64(gap - 1)
+			t.stats.Dropped += frame.Dropped
+		}
+	}
+	t.lastCounter = frame.Counter
+	t.hasLast = true
+}
+
+// Stats возвращает счётчики кадров.
+func (t *Tracker) Stats() FrameStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
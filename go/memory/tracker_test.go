@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerStamp(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		counters []uint32
+		dropped  []uint64 // ожидаемые Dropped по кадрам
+		repeated int      // номер повторного кадра, -1 — нет
+		reset    int      // номер кадра со сбросом счётчика, -1 — нет
+		want     FrameStats
+	}{
+		{"consecutive", []uint32{5, 6, 7}, []uint64{0, 0, 0}, -1, -1, FrameStats{Frames: 3}},
+		{"gap", []uint32{1, 2, 5}, []uint64{0, 0, 2}, -1, -1, FrameStats{Frames: 3, Dropped: 2}},
+		{"repeat", []uint32{7, 7, 8}, []uint64{0, 0, 0}, 1, -1, FrameStats{Frames: 3, Repeated: 1}},
+		{"wrap", []uint32{0xfffffffe, 0xffffffff, 1}, []uint64{0, 0, 1}, -1, -1, FrameStats{Frames: 3, Dropped: 1}},
+		{"reset", []uint32{1000, 1001, 3, 5}, []uint64{0, 0, 0, 1}, -1, 2, FrameStats{Frames: 4, Dropped: 1, Resets: 1}},
+		{"largest gap", []uint32{0, counterResetGap - 1}, []uint64{0, counterResetGap - 2}, -1, -1,
+			FrameStats{Frames: 2, Dropped: counterResetGap - 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := NewTracker(nil, "test")
+			start := time.Unix(1700000000, 0)
+			for i, c := range tc.counters {
+				frame := Frame{Counter: c, HasCounter: true}
+				tracker.Stamp(&frame, start.Add(time.Duration(i)*time.Millisecond))
+				if frame.Seq != uint64(i+1) || frame.SourceID != "test" {
+					t.Fatalf("frame %d: seq %d, source %q", i, frame.Seq, frame.SourceID)
+				}
+				if frame.Dropped != tc.dropped[i] || frame.Repeated != (i == tc.repeated) || frame.CounterReset != (i == tc.reset) {
+					t.Fatalf("frame %d (counter %d): dropped %d, repeated %v, reset %v",
+						i, c, frame.Dropped, frame.Repeated, frame.CounterReset)
+				}
+			}
+			if got := tracker.Stats(); got != tc.want {
+				t.Fatalf("stats %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTrackerStampWithoutCounter(t *testing.T) {
+	tracker := NewTracker(nil, "test")
+	for _, c := range []uint32{1, 1, 9} {
+		frame := Frame{Counter: c}
+		tracker.Stamp(&frame, time.Now())
+		if frame.Dropped != 0 || frame.Repeated || frame.CounterReset {
+			t.Fatalf("frame without counter: %+v", frame)
+		}
+	}
+	if got := tracker.Stats(); got != (FrameStats{Frames: 3}) {
+		t.Fatalf("stats %+v", got)
+	}
+}
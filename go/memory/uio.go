@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// UIOSource читает кадры из области памяти устройства Linux UIO (/dev/uioN).
+//
+// Область mapN отображается со смещением N*PageSize, как требует драйвер uio.
+// Адрес окна задаётся физическим адресом: смещение внутри области считается
+// от её начала, прочитанного из sysfs. Если sysfs недоступен, окно
+// начинается с начала области.
+type UIOSource struct {
+	path     string
+	mapIdx   int
+	window   Window
+	decoder  *Decoder
+	device   *Device
+	pingPong *PingPong
+}
+
+// NewUIOSource создаёт источник поверх устройства UIO.
+func NewUIOSource(path string, mapIdx int, window Window) *UIOSource {
+	if path == "" {
+		path = "/dev/uio0"
+	}
+	return &UIOSource{path: path, mapIdx: mapIdx, window: window}
+}
+
+func (s *UIOSource) Open(ctx context.Context) error {
+	decoder, err := NewDecoder(s.window.Encoding, s.window.FullScaleVolts)
+	if err != nil {
+		return err
+	}
+
+	length := s.window.MapLen()
+	skip := 0
+	if addr, size, err := uioMapInfo(s.path, s.mapIdx); err == nil {
+		offset := s.window.BaseAddress - addr
+		if offset < 0 || offset+int64(length) > size {
+			return fmt.Errorf("window %#x+%d is outside %s map%d (%#x+%d)",
+				s.window.BaseAddress, length, s.path, s.mapIdx, addr, size)
+		}
+		skip = int(offset)
+	}
+
+	device, err := openDevice(s.path, int64(s.mapIdx*PageSize), skip, length, s.window.PingPong)
+	if err != nil {
+		return err
+	}
+	return s.attach(device, decoder)
+}
+
+func (s *UIOSource) Next(ctx context.Context, frame *Frame) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.device == nil {
+		return fmt.Errorf("uio source %s is not open", s.path)
+	}
+	if s.pingPong != nil {
+		return s.pingPong.Next(ctx, s.decoder, frame)
+	}
+	return s.device.ReadFrame(s.window, s.decoder, frame)
+}
+
+func (s *UIOSource) Close() error {
+	if s.device == nil {
+		return nil
+	}
+	err := s.device.Close()
+	s.device = nil
+	s.pingPong = nil
+	return err
+}
+
+// attach запоминает открытое окно и, для PingPong, создаёт читателя буферов.
+func (s *UIOSource) attach(device *Device, decoder *Decoder) error {
+	if s.window.PingPong {
+		pingPong, err := NewPingPong(device, s.window, DefaultPingPongTimeout)
+		if err != nil {
+			device.Close()
+			return err
+		}
+		s.pingPong = pingPong
+	}
+	s.decoder = decoder
+	s.device = device
+	return nil
+}
+
+// uioMapInfo читает физический адрес и размер области mapN из sysfs.
+func uioMapInfo(path string, mapIdx int) (addr, size int64, err error) {
+	dir := fmt.Sprintf("/sys/class/uio/%s/maps/map%d", filepath.Base(path), mapIdx)
+	if addr, err = readSysfsInt(filepath.Join(dir, "addr")); err != nil {
+		return 0, 0, err
+	}
+	if size, err = readSysfsInt(filepath.Join(dir, "size")); err != nil {
+		return 0, 0, err
+	}
+	return addr, size, nil
+}
+
+func readSysfsInt(name string) (int64, error) {
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s failed: %w", name, err)
+	}
+	return val, nil
+}
@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	DefaultFrameSize   = 1024
+	DefaultBaseAddress = 0x2000000
+	DefaultFullScale   = 1.0 // В: полная шкала АЦП соответствует нормированной амплитуде
+	PageSize           = 4096
+)
+
+// Encoding — формат отсчётов АЦП в окне захвата.
+type Encoding string
+
+const (
+	// EncodingU16 — беззнаковые 16-битные отсчёты, little-endian.
+	EncodingU16 Encoding = "u16"
+	// EncodingS16 — знаковые 16-битные отсчёты (дополнительный код), little-endian.
+	EncodingS16 Encoding = "s16"
+	// EncodingU12Left — 12-битные беззнаковые отсчёты, выровненные по старшим битам 16-битного слова.
+	EncodingU12Left Encoding = "u12l"
+	// EncodingPacked2x16 — два знаковых 16-битных отсчёта в 32-битном слове:
+	// младшая половина — чётный отсчёт, старшая — нечётный.
+	EncodingPacked2x16 Encoding = "p2x16"
+)
+
+// Window описывает окно захвата в памяти FPGA.
+type Window struct {
+	BaseAddress    int64    // физический адрес первого отсчёта
+	FrameSize      int      // число отсчётов в кадре
+	Encoding       Encoding // формат отсчётов
+	FullScaleVolts float64  // размах входа АЦП, В
+	// HeaderBytes — служебный заголовок перед отсчётами. Если он не короче
+	// 4 байт, первое слово — аппаратный счётчик записанных кадров.
+	HeaderBytes int
+	// PingPong — окно разделено на два буфера, которые FPGA и HPS передают
+	// друг другу через слова состояния (см. PingPong).
+	PingPong bool
+}
+
+// DefaultWindow возвращает окно, соответствующее прошивке по умолчанию.
+func DefaultWindow() Window {
+	return Window{
+		BaseAddress:    DefaultBaseAddress,
+		FrameSize:      DefaultFrameSize,
+		Encoding:       EncodingU16,
+		FullScaleVolts: DefaultFullScale,
+	}
+}
+
+// Validate проверяет параметры окна.
+func (w Window) Validate() error {
+	if w.BaseAddress < 0 {
+		return fmt.Errorf("invalid base address %#x", w.BaseAddress)
+	}
+	if w.FrameSize <= 0 {
+		return fmt.Errorf("invalid frame size %d", w.FrameSize)
+	}
+	if w.HeaderBytes < 0 || w.HeaderBytes%4 != 0 {
+		return fmt.Errorf("invalid header size %d, want a multiple of 4", w.HeaderBytes)
+	}
+	if w.FullScaleVolts <= 0 {
+		return fmt.Errorf("invalid full scale %g V", w.FullScaleVolts)
+	}
+	if _, err := NewDecoder(w.Encoding, w.FullScaleVolts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ByteLen возвращает размер кадра в байтах вместе с заголовком.
+func (w Window) ByteLen() int {
+	return w.HeaderBytes + FrameBytes(w.Encoding, w.FrameSize)
+}
+
+// MapLen возвращает размер отображаемой области: кадр или, для PingPong,
+// управляющий блок и два буфера.
+func (w Window) MapLen() int {
+	if w.PingPong {
+		return pingPongControlBytes + 2*w.bufferStride()
+	}
+	return w.ByteLen()
+}
+
+// FrameBytes возвращает число байт, занимаемых n отсчётами в формате enc.
+func FrameBytes(enc Encoding, n int) int {
+	if enc == EncodingPacked2x16 {
+		return (n + 1) / 2 * 4
+	}
+	return n * 2
+}
+
+// Decoder переводит сырые отсчёты окна в вольты.
+//
+// Вес младшего разряда: lsb = FullScale / 2^bits, где bits — разрядность АЦП.
+// Беззнаковые коды дают диапазон [0, FullScale), знаковые — [-FullScale/2, FullScale/2).
+type Decoder struct {
+	encoding Encoding
+	lsb      float64
+}
+
+// NewDecoder создаёт декодер для формата enc и размаха fullScale вольт.
+func NewDecoder(enc Encoding, fullScale float64) (*Decoder, error) {
+	bits := 16
+	switch enc {
+	case EncodingU16, EncodingS16, EncodingPacked2x16:
+	case EncodingU12Left:
+		bits = 12
+	default:
+		return nil, fmt.Errorf("unknown sample encoding %q", enc)
+	}
+	return &Decoder{encoding: enc, lsb: fullScale / float64(uint(1)<<bits)}, nil
+}
+
+// Encoding возвращает формат, с которым работает декодер.
+func (d *Decoder) Encoding() Encoding {
+	return d.encoding
+}
+
+// Unpack извлекает n сырых 16-битных слов отсчётов из src в dst
+// (буфер переиспользуется) без перевода в вольты.
+func (d *Decoder) Unpack(src []byte, n int, dst []uint16) []uint16 {
+	dst = dst[:0]
+	if d.encoding == EncodingPacked2x16 {
+		for i := 0; i < n; i += 2 {
+			word := binary.LittleEndian.Uint32(src[i*2:])
+			dst = append(dst, uint16(word))
+			if i+1 < n {
+				dst = append(dst, uint16(word>>16))
+			}
+		}
+		return dst
+	}
+	for i := 0; i < n; i++ {
+		dst = append(dst, binary.LittleEndian.Uint16(src[i*2:]))
+	}
+	return dst
+}
+
+// Volts переводит сырое 16-битное слово отсчёта в вольты.
+func (d *Decoder) Volts(code uint16) float64 {
+	return d.Count(code) * d.lsb
+}
+
+// Count возвращает значение отсчёта в единицах младшего разряда АЦП:
+// со знаком для знаковых форматов, без выравнивающих битов для u12l.
+func (d *Decoder) Count(code uint16) float64 {
+	switch d.encoding {
+	case EncodingS16, EncodingPacked2x16:
+		return float64(int16(code))
+	case EncodingU12Left:
+		return float64(code >> 4)
+	default:
+		return float64(code)
+	}
+}
+
+// CountRange возвращает наименьший и наибольший отсчёт формата в единицах младшего разряда.
+func (d *Decoder) CountRange() (lo, hi float64) {
+	switch d.encoding {
+	case EncodingS16, EncodingPacked2x16:
+		return math.MinInt16, math.MaxInt16
+	case EncodingU12Left:
+		return 0, 0xFFF
+	default:
+		return 0, math.MaxUint16
+	}
+}
+
+// LSB возвращает вес младшего разряда, В.
+func (d *Decoder) LSB() float64 {
+	return d.lsb
+}
+
+// Signed сообщает, хранит ли формат знаковые отсчёты.
+func (d *Decoder) Signed() bool {
+	return d.encoding == EncodingS16 || d.encoding == EncodingPacked2x16
+}
+
+// Encode — обратное к Volts преобразование: квантует напряжение v в код АЦП
+// с насыщением на границах шкалы.
+func (d *Decoder) Encode(v float64) uint16 {
+	code := math.Round(v / d.lsb)
+	switch d.encoding {
+	case EncodingS16, EncodingPacked2x16:
+		return uint16(int16(math.Max(math.MinInt16, math.Min(math.MaxInt16, code))))
+	case EncodingU12Left:
+		return uint16(math.Max(0, math.Min(0xFFF, code))) << 4
+	default:
+		return uint16(math.Max(0, math.Min(math.MaxUint16, code)))
+	}
+}
+
+// Decode переводит n отсчётов из src в вольты, дописывая их в dst[:0].
+func (d *Decoder) Decode(src []byte, n int, dst []float64) []float64 {
+	dst = dst[:0]
+	if d.encoding == EncodingPacked2x16 {
+		for i := 0; i < n; i += 2 {
+			word := binary.LittleEndian.Uint32(src[i*2:])
+			dst = append(dst, d.Volts(uint16(word)))
+			if i+1 < n {
+				dst = append(dst, d.Volts(uint16(word>>16)))
+			}
+		}
+		return dst
+	}
+	for i := 0; i < n; i++ {
+		dst = append(dst, d.Volts(binary.LittleEndian.Uint16(src[i*2:])))
+	}
+	return dst
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecoderFixtures(t *testing.T) {
+	tests := []struct {
+		name      string
+		encoding  Encoding
+		fullScale float64
+		src       []byte
+		n         int
+		raw       []uint16
+		volts     []float64
+	}{
+		{
+			name:      "unsigned 16",
+			encoding:  EncodingU16,
+			fullScale: 65536,
+			src:       []byte{0x00, 0x00, 0x01, 0x00, 0xff, 0xff, 0x00, 0x80},
+			n:         4,
+			raw:       []uint16{0x0000, 0x0001, 0xffff, 0x8000},
+			volts:     []float64{0, 1, 65535, 32768},
+		},
+		{
+			name:      "signed 16",
+			encoding:  EncodingS16,
+			fullScale: 2,
+			src:       []byte{0x00, 0x00, 0xff, 0x7f, 0x00, 0x80, 0xff, 0xff},
+			n:         4,
+			raw:       []uint16{0x0000, 0x7fff, 0x8000, 0xffff},
+			volts:     []float64{0, 32767.0 / 32768, -1, -1.0 / 32768},
+		},
+		{
+			name:      "12-bit left-justified",
+			encoding:  EncodingU12Left,
+			fullScale: 4.096,
+			src:       []byte{0x00, 0x00, 0x10, 0x00, 0xf0, 0xff, 0x0f, 0x80},
+			n:         4,
+			raw:       []uint16{0x0000, 0x0010, 0xfff0, 0x800f},
+			volts:     []float64{0, 0.001, 4.095, 2.048},
+		},
+		{
+			name:      "packed 2x16",
+			encoding:  EncodingPacked2x16,
+			fullScale: 65536,
+			src:       []byte{0x01, 0x00, 0xff, 0xff, 0x00, 0x80, 0x10, 0x00},
+			n:         4,
+			raw:       []uint16{0x0001, 0xffff, 0x8000, 0x0010},
+			volts:     []float64{1, -1, -32768, 16},
+		},
+		{
+			name:      "packed 2x16, odd length",
+			encoding:  EncodingPacked2x16,
+			fullScale: 65536,
+			src:       []byte{0x02, 0x00, 0x03, 0x00, 0x04, 0x00, 0x55, 0x55},
+			n:         3,
+			raw:       []uint16{2, 3, 4},
+			volts:     []float64{2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, err := NewDecoder(tt.encoding, tt.fullScale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := FrameBytes(tt.encoding, tt.n); got > len(tt.src) {
+				t.Fatalf("FrameBytes = %d, fixture has %d bytes", got, len(tt.src))
+			}
+
+			raw := decoder.Unpack(tt.src, tt.n, nil)
+			if len(raw) != len(tt.raw) {
+				t.Fatalf("Unpack returned %d samples, want %d", len(raw), len(tt.raw))
+			}
+			for i := range raw {
+				if raw[i] != tt.raw[i] {
+					t.Errorf("raw[%d] = %#04x, want %#04x", i, raw[i], tt.raw[i])
+				}
+			}
+
+			volts := decoder.Decode(tt.src, tt.n, nil)
+			if len(volts) != len(tt.volts) {
+				t.Fatalf("Decode returned %d samples, want %d", len(volts), len(tt.volts))
+			}
+			for i := range volts {
+				if math.Abs(volts[i]-tt.volts[i]) > 1e-12 {
+					t.Errorf("volts[%d] = %g, want %g", i, volts[i], tt.volts[i])
+				}
+				if v := decoder.Volts(raw[i]); v != volts[i] {
+					t.Errorf("Volts(raw[%d]) = %g, Decode = %g", i, v, volts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWindowValidate(t *testing.T) {
+	if err := DefaultWindow().Validate(); err != nil {
+		t.Fatalf("default window: %v", err)
+	}
+
+	bad := []Window{
+		{BaseAddress: -1, FrameSize: 1, Encoding: EncodingU16, FullScaleVolts: 1},
+		{BaseAddress: 0, FrameSize: 0, Encoding: EncodingU16, FullScaleVolts: 1},
+		{BaseAddress: 0, FrameSize: 1, Encoding: "u24", FullScaleVolts: 1},
+		{BaseAddress: 0, FrameSize: 1, Encoding: EncodingS16, FullScaleVolts: 0},
+	}
+	for _, w := range bad {
+		if err := w.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", w)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fpga-ultrasound-go/memory"
+	"fpga-ultrasound-go/storage"
+	"io"
+	"log"
+	"time"
+)
+
+// pipeline — стадии сбора: источник, запись сессии, проверки кадра,
+// калибровка, накопление и разделение по каналам. Запись, калибровка
+// и накопление необязательны (nil).
+type pipeline struct {
+	tracker     *memory.Tracker
+	recorder    *memory.Recorder
+	monitor     *memory.HealthMonitor
+	decoder     *memory.Decoder
+	calibration *memory.Calibration
+	averager    *memory.Averager
+	layout      memory.ChannelLayout
+
+	queue        *blockQueue
+	blockSize    int     // отсчётов канала в блоке обработки
+	minBlock     int     // неполный блок при остановке обрабатывается, если не короче minBlock
+	sampleRateHz float64 // частота дискретизации для меток времени сырых отсчётов
+
+	split    memory.ChannelFrame // кадр, разделённый по каналам; буферы переиспользуются
+	averaged []float64           // результат накопления; буфер переиспользуется
+	cur      *block              // заполняемый блок
+
+	reason    stopReason // почему остановлен сбор; пишет acquire
+	processed uint64     // обработанные блоки; пишет process
+	failed    uint64     // блоки, обработка хотя бы одного канала которых завершилась ошибкой
+	skipped   uint64     // блоки, пропущенные после истечения времени на остановку
+}
+
+// stopReason — причина остановки сбора для итогов запуска.
+type stopReason string
+
+const (
+	stopEOF    stopReason = "eof"    // источник исчерпан
+	stopSignal stopReason = "signal" // получен сигнал остановки
+	stopHealth stopReason = "health" // проверка кадра потребовала остановки
+	stopError  stopReason = "error"  // ошибка источника или очереди
+)
+
+// acquire читает кадры, пока источник не исчерпан, не возникла ошибка
+// или не отменён ctx, собирает из них блоки не короче blockSize и ставит
+// их в очередь. Уже прочитанные кадры не теряются: очередь заполняется
+// без учёта отмены, неполный блок отправляется на обработку, затем
+// очередь закрывается.
+func (p *pipeline) acquire(ctx context.Context) {
+	log.Println("Чтение данных")
+	queueCtx := context.WithoutCancel(ctx)
+	defer p.queue.close()
+	defer p.flush(queueCtx)
+	if p.recorder != nil {
+		defer func() {
+			if err := p.recorder.Close(); err != nil {
+				log.Printf("❌ Session record error: %v", err)
+			}
+			log.Printf("Записано кадров: %d", p.recorder.Frames())
+		}()
+	}
+	for {
+		var frame memory.Frame
+		err := p.tracker.Next(ctx, &frame)
+		if errors.Is(err, io.EOF) {
+			log.Println("Источник кадров исчерпан")
+			p.reason = stopEOF
+			break
+		}
+		if ctx.Err() != nil {
+			log.Println("Сбор остановлен")
+			p.reason = stopSignal
+			break
+		}
+		if errors.Is(err, memory.ErrWaitTimeout) {
+			log.Printf("⚠️ %v", err)
+			continue
+		}
+		if err != nil {
+			log.Printf("❌ Memory read error: %v", err)
+			p.reason = stopError
+			break
+		}
+
+		if p.recorder != nil {
+			if err := p.recorder.Write(&frame); err != nil {
+				log.Printf("❌ Session record error: %v", err)
+			}
+		}
+
+		// Проверки выполняются до калибровки: пороги заданы в долях шкалы АЦП.
+		report, err := p.monitor.Check(&frame)
+		if err != nil {
+			log.Printf("❌ %v", err)
+			p.reason = stopHealth
+			break
+		}
+		if !report.OK() {
+			log.Printf("⚠️ Кадр #%d не прошёл проверку: %s", frame.Seq, report)
+		}
+		if frame.Seq%1000 == 0 {
+			hs := p.monitor.Stats()
+			log.Printf("Проверено кадров: %d, отброшено: %d, сбои: %v", hs.Frames, hs.Dropped, hs.Failures)
+		}
+		if report.Action == memory.ActionDrop {
+			continue
+		}
+
+		if p.calibration != nil {
+			if err := p.calibration.Apply(&frame, p.decoder); err != nil {
+				log.Printf("❌ Calibration error: %v", err)
+				continue
+			}
+		}
+
+		stats := p.tracker.Stats()
+		if frame.Dropped > 0 {
+			log.Printf("⚠️ Перед кадром #%d потеряно кадров: %d (всего %d)", frame.Seq, frame.Dropped, stats.Dropped)
+		}
+		if frame.CounterReset {
+			log.Printf("⚠️ Перед кадром #%d аппаратный счётчик сброшен, отсчёт с %d", frame.Seq, frame.Counter)
+		}
+		if frame.Seq%1000 == 0 {
+			log.Printf("Кадров: %d, потеряно: %d, повторных чтений: %d", stats.Frames, stats.Dropped, stats.Repeated)
+			if p.recorder != nil {
+				if err := p.recorder.Flush(); err != nil {
+					log.Printf("❌ Session record error: %v", err)
+				}
+			}
+		}
+		if err := storage.SaveFrameLog("./"+FileWithTime+"_frames.csv", &frame, stats); err != nil {
+			log.Printf("❌ frame log save error: %v", err)
+		}
+		if frame.Repeated {
+			continue
+		}
+		if p.averager != nil {
+			if !p.averager.Add(&frame) {
+				log.Printf("⚠️ Кадр #%d отброшен: зондирующий импульс не совпал с опорным", frame.Seq)
+				continue
+			}
+			if frame.Seq%1000 == 0 {
+				avg := p.averager.Stats()
+				log.Printf("Накоплено: %d, отброшено: %d, выигрыш ОСШ: %.1f дБ (ожидаемый %.1f дБ)",
+					avg.Accepted, avg.Rejected, avg.MeasuredDB, avg.ExpectedDB)
+			}
+			if !p.averager.Ready() {
+				continue
+			}
+			p.averaged = p.averager.Result(p.averaged)
+			frame.Samples = p.averaged
+		}
+
+		if err := p.layout.Deinterleave(&frame, &p.split); err != nil {
+			log.Printf("❌ Channel split error: %v", err)
+			continue
+		}
+
+		if len(p.split.Samples) == 1 {
+			err = storage.SaveFrame("./"+FileWithTime+"_RAW_result.csv", &frame, p.sampleRateHz)
+		} else {
+			err = storage.SaveChannelFrame("./"+FileWithTime+"_RAW_result.csv", &p.split, p.sampleRateHz)
+		}
+		if err != nil {
+			log.Printf("❌ raw save error: %v", err)
+		}
+
+		if err := p.emit(queueCtx, &frame); err != nil {
+			log.Printf("❌ Processing queue error: %v", err)
+			p.reason = stopError
+			break
+		}
+		if frame.Seq%1000 == 0 && p.queue.Dropped() > 0 {
+			log.Printf("⚠️ Обработка не успевает: выброшено блоков %d", p.queue.Dropped())
+		}
+	}
+}
+
+// emit добавляет разделённый кадр к заполняемому блоку и ставит блок
+// в очередь, когда в нём набралось blockSize отсчётов канала.
+func (p *pipeline) emit(ctx context.Context, frame *memory.Frame) error {
+	if p.cur == nil {
+		p.cur = p.queue.get(len(p.split.Samples))
+	}
+	if p.cur.Frames == 0 {
+		p.cur.Seq, p.cur.Timestamp = frame.Seq, frame.Timestamp
+	}
+	for c, samples := range p.split.Samples {
+		p.cur.Samples[c] = append(p.cur.Samples[c], samples...)
+	}
+	p.cur.Frames++
+	if p.cur.Len() < p.blockSize {
+		return nil
+	}
+	b := p.cur
+	p.cur = nil
+	return p.queue.put(ctx, b)
+}
+
+// flush отправляет на обработку неполный блок, оставшийся после остановки сбора.
+func (p *pipeline) flush(ctx context.Context) {
+	b := p.cur
+	p.cur = nil
+	if b == nil || b.Frames == 0 {
+		return
+	}
+	if b.Len() < p.minBlock {
+		log.Printf("⚠️ Неполный блок из %d кадров (%d отсчётов) короче ядра фильтра и не обработан", b.Frames, b.Len())
+		p.queue.release(b)
+		return
+	}
+	log.Printf("Обработка неполного блока из %d кадров (%d отсчётов)", b.Frames, b.Len())
+	if err := p.queue.put(ctx, b); err != nil {
+		log.Printf("❌ Processing queue error: %v", err)
+	}
+}
+
+// process обрабатывает блоки из очереди, пока она не закрыта. Каждый канал
+// обрабатывается отдельно; у одного канала имена файлов прежние.
+// После отмены ctx оставшиеся блоки только извлекаются из очереди, чтобы
+// сбор мог завершиться.
+func (p *pipeline) process(ctx context.Context, params processingParams) {
+	for b := range p.queue.blocks {
+		if ctx.Err() != nil {
+			p.skipped++
+			p.queue.release(b)
+			continue
+		}
+		failed := false
+		for c, data := range b.Samples {
+			name, suffix := p.layout.Channels[c].Name, ""
+			if len(b.Samples) > 1 {
+				suffix = "_" + name
+			}
+			tof, err := processing(ctx, data, params, suffix)
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				log.Printf("❌ Processing error (%s, block #%d): %v", name, b.Seq, err)
+				failed = true
+				continue
+			}
+			if err := storage.SaveTimeOfFlight("./"+FileWithTime+"_TimeOfFlight.csv", b.Seq, b.Timestamp, name, tof); err != nil {
+				log.Printf("❌ ToF save error: %v", err)
+			}
+		}
+		switch {
+		case ctx.Err() != nil:
+			p.skipped++
+		case failed:
+			p.failed++
+		default:
+			p.processed++
+		}
+		p.queue.release(b)
+	}
+}
+
+// runSummary — итоги запуска, которые пишутся в журнал и в SummaryFile.
+type runSummary struct {
+	Source        string     `json:"source"`
+	Started       time.Time  `json:"started"`
+	Finished      time.Time  `json:"finished"`
+	Duration      string     `json:"duration"`
+	StopReason    stopReason `json:"stop_reason"`
+	Frames        uint64     `json:"frames"`
+	Dropped       uint64     `json:"dropped"`
+	Repeated      uint64     `json:"repeated"`
+	CounterResets uint64     `json:"counter_resets"`
+	HealthDropped uint64     `json:"health_dropped"`
+	Recorded      uint64     `json:"recorded"`
+	Blocks        uint64     `json:"blocks_processed"`
+	BlocksFailed  uint64     `json:"blocks_failed"`
+	BlocksDropped uint64     `json:"blocks_dropped"`
+	BlocksSkipped uint64     `json:"blocks_skipped"`
+}
+
+// summary собирает итоги; вызывается после завершения acquire и process.
+func (p *pipeline) summary(source string, started time.Time) runSummary {
+	finished := time.Now()
+	stats := p.tracker.Stats()
+	s := runSummary{
+		Source:        source,
+		Started:       started,
+		Finished:      finished,
+		Duration:      finished.Sub(started).Round(time.Millisecond).String(),
+		StopReason:    p.reason,
+		Frames:        stats.Frames,
+		Dropped:       stats.Dropped,
+		Repeated:      stats.Repeated,
+		CounterResets: stats.Resets,
+		HealthDropped: p.monitor.Stats().Dropped,
+		Blocks:        p.processed,
+		BlocksFailed:  p.failed,
+		BlocksDropped: p.queue.Dropped(),
+		BlocksSkipped: p.skipped,
+	}
+	if p.recorder != nil {
+		s.Recorded = p.recorder.Frames()
+	}
+	return s
+}
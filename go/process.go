@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fpga-ultrasound-go/memory"
+	"fpga-ultrasound-go/storage"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// runProcess — команда process: цепочка ultrasignal для сохранённых файлов
+// отсчётов (например, [Time]_RAW_result.csv). Каждый файл обрабатывается
+// целиком, результаты пишутся в текущий каталог, время пролёта —
+// в [Time]_TimeOfFlight.csv с номером файла вместо номера кадра.
+func runProcess(args []string) int {
+	fs := newFlagSet("process", "<файл.csv>...")
+	bound := settingsFlags(fs)
+	configFile := fs.String("config", "", "файл настроек обработки (JSON); переопределяется переменными ULTRASOUND_* и флагами")
+	fullScale := fs.Float64("full-scale", memory.DefaultFullScale, "полная шкала отсчётов файла: пороги заданы в её долях")
+	unit := fs.String("unit", string(memory.UnitADCVolts), "единица отсчётов файла: V_adc, V, counts или FS")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		return usageError(fs, "no input files")
+	}
+	if *fullScale <= 0 {
+		return usageError(fs, "invalid full scale %g", *fullScale)
+	}
+
+	logFile, err := logSettings()
+	if err != nil {
+		log.Print(err)
+	}
+	defer logFile.Close()
+
+	settings, err := loadRunSettings(*configFile, fs, bound)
+	if err != nil {
+		return fail("Settings error: %v", err)
+	}
+	params := processingParams{
+		Settings:  settings,
+		LowHz:     settings.LowCutoffHz,
+		HighHz:    settings.HighCutoffHz,
+		Unit:      memory.Unit(*unit),
+		FullScale: *fullScale,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	code := exitOK
+	for i, path := range fs.Args() {
+		if ctx.Err() != nil {
+			log.Printf("Обработка прервана, файлов пропущено: %d", fs.NArg()-i)
+			break
+		}
+		if err := processFile(ctx, path, uint64(i+1), fs.NArg() > 1, params); err != nil {
+			code = fail("Process %s error: %v", path, err)
+		}
+	}
+	return code
+}
+
+// processFile обрабатывает каналы одного файла. При нескольких файлах
+// к именам результатов добавляется имя исходного файла.
+func processFile(ctx context.Context, path string, seq uint64, many bool, params processingParams) error {
+	stored, err := storage.ReadSamples(path)
+	if err != nil {
+		return err
+	}
+	log.Printf("Файл %s: каналов %d, отсчётов %d", path, len(stored.Samples), len(stored.Samples[0]))
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	for c, data := range stored.Samples {
+		name, suffix := stem, ""
+		if many {
+			suffix = "_" + stem
+		}
+		if len(stored.Channels) > 1 {
+			name = stored.Channels[c]
+			suffix += "_" + name
+		}
+		if len(data) < params.FIRKernelSize {
+			log.Printf("⚠️ %s: %d отсчётов короче ядра фильтра (%d), канал пропущен", name, len(data), params.FIRKernelSize)
+			continue
+		}
+		tof, err := processing(ctx, data, params, suffix)
+		if err != nil {
+			return err
+		}
+		if err := storage.SaveTimeOfFlight("./"+FileWithTime+"_TimeOfFlight.csv", seq, stored.Start, name, tof); err != nil {
+			log.Printf("❌ ToF save error: %v", err)
+		}
+	}
+	return nil
+}
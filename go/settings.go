@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"fpga-ultrasound-go/ultrasignal"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SettingsEnvPrefix — префикс переменных окружения, переопределяющих настройки:
+// ULTRASOUND_<ключ JSON в верхнем регистре>, например ULTRASOUND_FIR_KERNEL_SIZE.
+const SettingsEnvPrefix = "ULTRASOUND_"
+
+// Settings — параметры обработки. Значения по умолчанию совпадают с прежними
+// константами main.go, поэтому без файла настроек поведение не меняется.
+//
+// Источники применяются по порядку: значения по умолчанию, файл (-config),
+// переменные окружения, явно заданные флаги.
+type Settings struct {
+	SourceRateHz  float64 `json:"source_rate_hz" help:"частота выдачи отсчётов file и synthetic, Гц"`
+	SampleRateHz  float64 `json:"sample_rate_hz" help:"частота дискретизации АЦП, Гц"`
+	FilterWindow  int     `json:"filter_window" help:"окно скользящего среднего, отсчёты"`
+	FIRKernelSize int     `json:"fir_kernel_size" help:"длина ядра полосового КИХ-фильтра (нечётная)"`
+	FilterPhase   string  `json:"filter_phase" help:"применение КИХ-фильтра: causal, same или zero-phase; задержка учитывается во времени пролёта"`
+	FFTKernelSize int     `json:"fft_kernel_size" help:"размер окна FFT, отсчёты"`
+	LowCutoffHz   float64 `json:"low_cutoff_hz" help:"нижняя частота среза без генератора, Гц"`
+	HighCutoffHz  float64 `json:"high_cutoff_hz" help:"верхняя частота среза без генератора, Гц"`
+	SpectrumMinHz float64 `json:"spectrum_min_hz" help:"нижняя граница логарифмической сетки спектра, Гц"`
+	SpectrumMaxHz float64 `json:"spectrum_max_hz" help:"верхняя граница логарифмической сетки спектра, Гц"`
+	AFCScale      string  `json:"afc_scale" help:"сетка частотной характеристики фильтра: linear (0…f_s/2) или log (afc_min_hz…f_s/2)"`
+	AFCMinHz      float64 `json:"afc_min_hz" help:"нижняя граница логарифмической сетки характеристики фильтра, Гц"`
+	AFCPoints     int     `json:"afc_points" help:"число точек частотной характеристики фильтра"`
+	Threshold     float64 `json:"threshold" help:"порог отсечки отсчётов, доля полной шкалы"`
+	EchoThreshold float64 `json:"echo_threshold" help:"порог обнаружения эха, доля полной шкалы"`
+	DeadZoneUS    float64 `json:"dead_zone_us" help:"мёртвая зона от начала блока, мкс: эхо в ней (зондирующий импульс) не учитывается во времени пролёта"`
+	ThicknessMM   float64 `json:"thickness_mm" help:"толщина образца, мм"`
+	Mode          string  `json:"mode" help:"модальный режим: A0 или S0"`
+	QueueDepth    int     `json:"queue_depth" help:"блоков в очереди между сбором и обработкой"`
+	Backpressure  string  `json:"backpressure" help:"переполнение очереди: block — ждать, drop-oldest — выбросить старый блок"`
+}
+
+// DefaultSettings возвращает прежние значения констант.
+func DefaultSettings() Settings {
+	return Settings{
+		SourceRateHz:  1e5, // 0.1 МГц
+		SampleRateHz:  1e6, // 10 × частота выдачи
+		FilterWindow:  5,
+		FIRKernelSize: 101,
+		FilterPhase:   string(ultrasignal.PhaseCausal),
+		FFTKernelSize: 1000,
+		LowCutoffHz:   1e-3, // 0.001 Гц
+		HighCutoffHz:  1e6,  // 1 МГц
+		SpectrumMinHz: 1e-3,
+		SpectrumMaxHz: 1e6,
+		AFCScale:      string(ultrasignal.GridLinear),
+		AFCMinHz:      100,
+		AFCPoints:     512,
+		Threshold:     0.5,
+		EchoThreshold: 0.6,
+		DeadZoneUS:    100, // зондирующий импульс и его хвост
+		ThicknessMM:   10.0,
+		Mode:          "A0",
+		QueueDepth:    4,
+		Backpressure:  string(BackpressureBlock),
+	}
+}
+
+// Validate проверяет согласованность параметров.
+func (s *Settings) Validate() error {
+	var errs []error
+	if s.SourceRateHz <= 0 {
+		errs = append(errs, fmt.Errorf("invalid source rate %g Hz", s.SourceRateHz))
+	}
+	if s.SampleRateHz <= 0 {
+		errs = append(errs, fmt.Errorf("invalid sample rate %g Hz", s.SampleRateHz))
+	}
+	if s.FilterWindow < 1 {
+		errs = append(errs, fmt.Errorf("invalid filter window %d", s.FilterWindow))
+	}
+	if s.FIRKernelSize < 3 || s.FIRKernelSize%2 == 0 {
+		errs = append(errs, fmt.Errorf("FIR kernel size %d must be odd and at least 3", s.FIRKernelSize))
+	}
+	switch ultrasignal.FilterPhase(s.FilterPhase) {
+	case ultrasignal.PhaseCausal, ultrasignal.PhaseSame, ultrasignal.PhaseZero:
+	default:
+		errs = append(errs, fmt.Errorf("unknown filter phase %q, want causal, same or zero-phase", s.FilterPhase))
+	}
+	if s.FFTKernelSize < 2 {
+		errs = append(errs, fmt.Errorf("invalid FFT kernel size %d", s.FFTKernelSize))
+	}
+	if s.LowCutoffHz < 0 || s.HighCutoffHz <= s.LowCutoffHz {
+		errs = append(errs, fmt.Errorf("invalid cutoff band %g–%g Hz", s.LowCutoffHz, s.HighCutoffHz))
+	}
+	if s.SpectrumMinHz <= 0 || s.SpectrumMaxHz <= s.SpectrumMinHz {
+		errs = append(errs, fmt.Errorf("invalid spectrum range %g–%g Hz", s.SpectrumMinHz, s.SpectrumMaxHz))
+	}
+	switch ultrasignal.GridScale(s.AFCScale) {
+	case ultrasignal.GridLinear:
+	case ultrasignal.GridLog:
+		if s.AFCMinHz <= 0 || s.AFCMinHz >= s.SampleRateHz/2 {
+			errs = append(errs, fmt.Errorf("filter response lower bound %g Hz is outside (0, %g)", s.AFCMinHz, s.SampleRateHz/2))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown filter response scale %q, want linear or log", s.AFCScale))
+	}
+	if s.AFCPoints < 2 {
+		errs = append(errs, fmt.Errorf("invalid filter response points %d", s.AFCPoints))
+	}
+	if s.Threshold < 0 || s.Threshold > 1 {
+		errs = append(errs, fmt.Errorf("threshold %g is outside [0, 1] of full scale", s.Threshold))
+	}
+	if s.EchoThreshold <= 0 || s.EchoThreshold > 1 {
+		errs = append(errs, fmt.Errorf("echo threshold %g is outside (0, 1] of full scale", s.EchoThreshold))
+	}
+	if s.DeadZoneUS < 0 {
+		errs = append(errs, fmt.Errorf("invalid dead zone %g µs", s.DeadZoneUS))
+	}
+	if s.ThicknessMM <= 0 {
+		errs = append(errs, fmt.Errorf("invalid thickness %g mm", s.ThicknessMM))
+	}
+	if s.Mode != "A0" && s.Mode != "S0" {
+		errs = append(errs, fmt.Errorf("unknown mode %q, want A0 or S0", s.Mode))
+	}
+	if s.QueueDepth < 1 {
+		errs = append(errs, fmt.Errorf("invalid queue depth %d", s.QueueDepth))
+	}
+	if b := Backpressure(s.Backpressure); b != BackpressureBlock && b != BackpressureDropOldest {
+		errs = append(errs, fmt.Errorf("unknown backpressure policy %q, want block or drop-oldest", s.Backpressure))
+	}
+	return errors.Join(errs...)
+}
+
+// ReadSettings накладывает на s значения из JSON-файла. Неизвестные ключи — ошибка,
+// чтобы опечатка в имени параметра не проходила молча.
+func ReadSettings(path string, s *Settings) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s failed: %w", path, err)
+	}
+	defer file.Close()
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return fmt.Errorf("parse %s failed: %w", path, err)
+	}
+	return nil
+}
+
+// settingsField — параметр настроек с его ключом и подсказкой.
+type settingsField struct {
+	key   string // ключ JSON
+	help  string
+	value reflect.Value
+}
+
+func settingsFields(s *Settings) []settingsField {
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	fields := make([]settingsField, t.NumField())
+	for i := range fields {
+		f := t.Field(i)
+		fields[i] = settingsField{key: f.Tag.Get("json"), help: f.Tag.Get("help"), value: v.Field(i)}
+	}
+	return fields
+}
+
+// set разбирает строковое значение по типу поля.
+func (f settingsField) set(raw string) error {
+	switch f.value.Kind() {
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.key, err)
+		}
+		f.value.SetInt(int64(n))
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.key, err)
+		}
+		f.value.SetFloat(x)
+	case reflect.String:
+		f.value.SetString(raw)
+	default:
+		return fmt.Errorf("%s: unsupported setting type %s", f.key, f.value.Type())
+	}
+	return nil
+}
+
+// ApplyEnv накладывает значения переменных окружения ULTRASOUND_*.
+func (s *Settings) ApplyEnv(lookup func(string) (string, bool)) error {
+	for _, f := range settingsFields(s) {
+		raw, ok := lookup(SettingsEnvPrefix + strings.ToUpper(f.key))
+		if !ok {
+			continue
+		}
+		if err := f.set(raw); err != nil {
+			return fmt.Errorf("environment %s%s: %w", SettingsEnvPrefix, strings.ToUpper(f.key), err)
+		}
+	}
+	return nil
+}
+
+// settingsFlags регистрирует флаг для каждого параметра: ключ JSON с дефисами
+// вместо подчёркиваний. Значения флагов копируются в настройки только
+// для явно заданных флагов (см. ApplyFlags).
+func settingsFlags(fs *flag.FlagSet) *Settings {
+	bound := DefaultSettings()
+	for _, f := range settingsFields(&bound) {
+		name := strings.ReplaceAll(f.key, "_", "-")
+		switch p := f.value.Addr().Interface().(type) {
+		case *int:
+			fs.IntVar(p, name, *p, f.help)
+		case *float64:
+			fs.Float64Var(p, name, *p, f.help)
+		case *string:
+			fs.StringVar(p, name, *p, f.help)
+		}
+	}
+	return &bound
+}
+
+// ApplyFlags копирует в s параметры, флаги которых заданы в командной строке.
+func (s *Settings) ApplyFlags(fs *flag.FlagSet, bound *Settings) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	src := settingsFields(bound)
+	for i, f := range settingsFields(s) {
+		if set[strings.ReplaceAll(f.key, "_", "-")] {
+			f.value.Set(src[i].value)
+		}
+	}
+}
+
+// LoadSettings собирает действующие настройки: значения по умолчанию, файл path
+// (если задан), окружение и явно заданные флаги из fs.
+func LoadSettings(path string, fs *flag.FlagSet, bound *Settings) (Settings, error) {
+	s := DefaultSettings()
+	if path != "" {
+		if err := ReadSettings(path, &s); err != nil {
+			return s, err
+		}
+	}
+	if err := s.ApplyEnv(os.LookupEnv); err != nil {
+		return s, err
+	}
+	s.ApplyFlags(fs, bound)
+	if err := s.Validate(); err != nil {
+		return s, fmt.Errorf("invalid settings: %w", err)
+	}
+	return s, nil
+}
+
+// String возвращает настройки в виде JSON для журнала.
+func (s Settings) String() string {
+	// Поля — числа и строки, поэтому кодирование не завершается ошибкой.
+	raw, _ := json.MarshalIndent(s, "", "  ")
+	return string(raw)
+}
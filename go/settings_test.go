@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultSettingsValid(t *testing.T) {
+	s := DefaultSettings()
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if s.SampleRateHz != 10*s.SourceRateHz {
+		t.Fatalf("sample rate %g is not 10 × source rate %g", s.SampleRateHz, s.SourceRateHz)
+	}
+}
+
+func TestLoadSettingsPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	raw := `{"fir_kernel_size": 51, "thickness_mm": 5, "mode": "S0", "filter_window": 7}`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SettingsEnvPrefix+"THICKNESS_MM", "3.5")
+	t.Setenv(SettingsEnvPrefix+"FILTER_WINDOW", "9")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	bound := settingsFlags(fs)
+	if err := fs.Parse([]string{"-filter-window", "11"}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadSettings(path, fs, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DefaultSettings()
+	want.FIRKernelSize = 51 // файл
+	want.Mode = "S0"        // файл
+	want.ThicknessMM = 3.5  // окружение поверх файла
+	want.FilterWindow = 11  // флаг поверх окружения и файла
+	if s != want {
+		t.Fatalf("settings:\n%s\nwant:\n%s", s, want)
+	}
+}
+
+func TestLoadSettingsErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, raw := range map[string]string{
+		"typo":    `{"fir_kernel": 51}`,
+		"even":    `{"fir_kernel_size": 50}`,
+		"band":    `{"low_cutoff_hz": 2e6}`,
+		"mode":    `{"mode": "SH0"}`,
+		"phase":   `{"filter_phase": "reverse"}`,
+		"afc":     `{"afc_scale": "octave"}`,
+		"afc log": `{"afc_scale": "log", "afc_min_hz": 0}`,
+		"garbage": `{"threshold": "half"}`,
+		"dead":    `{"dead_zone_us": -1}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		if _, err := LoadSettings(path, fs, settingsFlags(fs)); err == nil {
+			t.Errorf("%s: settings %s accepted", name, raw)
+		}
+	}
+
+	t.Setenv(SettingsEnvPrefix+"FFT_KERNEL_SIZE", "many")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if _, err := LoadSettings("", fs, settingsFlags(fs)); err == nil {
+		t.Error("malformed environment value accepted")
+	}
+}
@@ -0,0 +1,357 @@
+package sopc
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnmapped — ведомый интерфейс не подключён ни к одному ведущему,
+// и адреса в пространстве процессора у него нет.
+var ErrUnmapped = errors.New("not mapped by any master")
+
+// System — карта памяти системы Qsys, извлечённая из файла .sopcinfo.
+type System struct {
+	Name        string
+	Modules     []Module
+	Connections []Connection
+}
+
+// Module — экземпляр компонента Qsys.
+type Module struct {
+	Name       string
+	Kind       string
+	Path       string
+	Interfaces []Interface
+}
+
+// Interface — точка подключения модуля.
+// Для ведомых (slave) интерфейсов Span хранит размер адресного окна в байтах.
+type Interface struct {
+	Name string
+	Kind string
+	Span uint64
+}
+
+// IsSlave сообщает, является ли интерфейс ведомым Avalon-MM или AXI.
+func (i Interface) IsSlave() bool {
+	return isMemoryMapped(i.Kind) && strings.HasSuffix(i.Kind, "_slave")
+}
+
+// IsMaster сообщает, является ли интерфейс ведущим Avalon-MM или AXI.
+func (i Interface) IsMaster() bool {
+	return isMemoryMapped(i.Kind) && strings.HasSuffix(i.Kind, "_master")
+}
+
+func isMemoryMapped(kind string) bool {
+	return strings.Contains(kind, "avalon") || strings.Contains(kind, "axi")
+}
+
+// Connection — соединение ведущего интерфейса с ведомым.
+// Master и Slave записываются как "модуль.интерфейс",
+// BaseAddress — адрес ведомого в пространстве ведущего.
+type Connection struct {
+	Master      string
+	Slave       string
+	BaseAddress uint64
+}
+
+// Region — адресное окно ведомого интерфейса в пространстве процессора.
+type Region struct {
+	Name   string // "модуль.интерфейс"
+	Kind   string // тип интерфейса, например avalon_slave
+	Base   uint64 // абсолютный адрес
+	Span   uint64 // размер окна в байтах
+	Master string // корневой ведущий, через которого получен адрес
+	Mapped bool   // интерфейс подключён к ведущему; иначе Base и Master пусты
+}
+
+// End возвращает первый адрес за пределами окна.
+func (r Region) End() uint64 {
+	return r.Base + r.Span
+}
+
+// Load читает и разбирает файл .sopcinfo.
+func Load(path string) (*System, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s failed: %w", path, err)
+	}
+	defer file.Close()
+
+	sys, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s failed: %w", path, err)
+	}
+	return sys, nil
+}
+
+// Parse разбирает содержимое .sopcinfo.
+func Parse(r io.Reader) (*System, error) {
+	var report xmlReport
+	if err := xml.NewDecoder(r).Decode(&report); err != nil {
+		return nil, err
+	}
+
+	sys := &System{Name: report.Name}
+	blockSpans := make(map[string]uint64)
+
+	for _, m := range report.Modules {
+		module := Module{Name: m.Name, Kind: m.Kind, Path: m.Path}
+		for _, i := range m.Interfaces {
+			iface := Interface{Name: i.Name, Kind: i.Kind}
+			if v, ok := i.parameter("addressSpan"); ok {
+				span, err := parseUint(v)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s addressSpan: %w", m.Name, i.Name, err)
+				}
+				iface.Span = span
+			}
+			module.Interfaces = append(module.Interfaces, iface)
+
+			for _, b := range i.MemoryBlocks {
+				if span, err := parseUint(b.Span); err == nil {
+					blockSpans[b.Name] = span
+				}
+			}
+		}
+		sys.Modules = append(sys.Modules, module)
+	}
+
+	for _, c := range report.Connections {
+		v, ok := c.parameter("baseAddress")
+		if !ok {
+			continue
+		}
+		base, err := parseUint(v)
+		if err != nil {
+			return nil, fmt.Errorf("connection %s baseAddress: %w", c.Name, err)
+		}
+		sys.Connections = append(sys.Connections, Connection{Master: c.Start, Slave: c.End, BaseAddress: base})
+	}
+
+	// Некоторые компоненты HPS не указывают addressSpan у интерфейса,
+	// но размер окна есть в memoryBlock ведущего.
+	for mi := range sys.Modules {
+		module := &sys.Modules[mi]
+		for ii := range module.Interfaces {
+			iface := &module.Interfaces[ii]
+			if iface.Span == 0 {
+				iface.Span = blockSpans[module.Name+"."+iface.Name]
+			}
+		}
+	}
+
+	return sys, nil
+}
+
+// Module возвращает модуль по имени.
+func (s *System) Module(name string) (Module, bool) {
+	for _, m := range s.Modules {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Module{}, false
+}
+
+// Interface возвращает интерфейс по имени "модуль.интерфейс".
+func (s *System) Interface(name string) (Interface, bool) {
+	moduleName, ifaceName, ok := strings.Cut(name, ".")
+	if !ok {
+		return Interface{}, false
+	}
+	module, ok := s.Module(moduleName)
+	if !ok {
+		return Interface{}, false
+	}
+	for _, i := range module.Interfaces {
+		if i.Name == ifaceName {
+			return i, true
+		}
+	}
+	return Interface{}, false
+}
+
+// Lookup находит абсолютное адресное окно ведомого интерфейса,
+// например "onchip_memory2_0.s1".
+//
+// Адрес складывается по цепочке мостов: базовый адрес соединения плюс
+// адрес ведомого интерфейса моста, через который виден ведущий.
+func (s *System) Lookup(name string) (Region, error) {
+	iface, ok := s.Interface(name)
+	if !ok {
+		return Region{}, fmt.Errorf("interface %q not found in system %s", name, s.Name)
+	}
+	if !iface.IsSlave() {
+		return Region{}, fmt.Errorf("interface %q is %s, not a memory-mapped slave", name, iface.Kind)
+	}
+
+	base, root, err := s.resolve(name, 0)
+	if err != nil {
+		return Region{}, err
+	}
+	return Region{Name: name, Kind: iface.Kind, Base: base, Span: iface.Span, Master: root, Mapped: true}, nil
+}
+
+// Regions возвращает все ведомые интерфейсы: сначала имеющие адрес,
+// по возрастанию адреса, затем не подключённые ни к одному ведущему
+// (Mapped = false) в порядке описания в файле. Интерфейсы, адрес которых
+// не удалось вычислить по другой причине, пропускаются.
+func (s *System) Regions() []Region {
+	var regions []Region
+	for _, m := range s.Modules {
+		for _, i := range m.Interfaces {
+			if !i.IsSlave() {
+				continue
+			}
+			name := m.Name + "." + i.Name
+			region, err := s.Lookup(name)
+			switch {
+			case err == nil:
+				regions = append(regions, region)
+			case errors.Is(err, ErrUnmapped):
+				regions = append(regions, Region{Name: name, Kind: i.Kind, Span: i.Span})
+			}
+		}
+	}
+	sort.SliceStable(regions, func(a, b int) bool {
+		if regions[a].Mapped != regions[b].Mapped {
+			return regions[a].Mapped
+		}
+		return regions[a].Mapped && regions[a].Base < regions[b].Base
+	})
+	return regions
+}
+
+const maxBridgeDepth = 16
+
+// resolve возвращает абсолютный адрес ведомого slave и имя корневого ведущего.
+func (s *System) resolve(slave string, depth int) (uint64, string, error) {
+	if depth > maxBridgeDepth {
+		return 0, "", fmt.Errorf("bridge chain to %q is too deep", slave)
+	}
+
+	for _, c := range s.Connections {
+		if c.Slave != slave {
+			continue
+		}
+		bridge, ok := s.bridgeSlave(c.Master)
+		if !ok {
+			return c.BaseAddress, c.Master, nil
+		}
+		base, root, err := s.resolve(bridge, depth+1)
+		if err != nil {
+			return 0, "", err
+		}
+		return base + c.BaseAddress, root, nil
+	}
+	return 0, "", fmt.Errorf("interface %q is %w", slave, ErrUnmapped)
+}
+
+// bridgeSlave находит ведомый интерфейс моста, через который процессор
+// обращается к ведущему master. Пара ищется по имени (axi_h2f_lw → h2f_lw),
+// а если у модуля единственный подключённый ведомый — берётся он.
+func (s *System) bridgeSlave(master string) (string, bool) {
+	moduleName, masterName, ok := strings.Cut(master, ".")
+	if !ok {
+		return "", false
+	}
+	module, ok := s.Module(moduleName)
+	if !ok {
+		return "", false
+	}
+
+	var mapped []string
+	for _, i := range module.Interfaces {
+		if !i.IsSlave() {
+			continue
+		}
+		name := moduleName + "." + i.Name
+		if !s.isConnected(name) {
+			continue
+		}
+		if strings.HasSuffix(i.Name, masterName) {
+			return name, true
+		}
+		mapped = append(mapped, name)
+	}
+	if len(mapped) == 1 {
+		return mapped[0], true
+	}
+	return "", false
+}
+
+func (s *System) isConnected(slave string) bool {
+	for _, c := range s.Connections {
+		if c.Slave == slave {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUint разбирает десятичные и шестнадцатеричные (0x...) значения.
+func parseUint(v string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimSpace(v), 0, 64)
+}
+
+type xmlReport struct {
+	Name        string          `xml:"name,attr"`
+	Modules     []xmlModule     `xml:"module"`
+	Connections []xmlConnection `xml:"connection"`
+}
+
+type xmlModule struct {
+	Name       string         `xml:"name,attr"`
+	Kind       string         `xml:"kind,attr"`
+	Path       string         `xml:"path,attr"`
+	Interfaces []xmlInterface `xml:"interface"`
+}
+
+type xmlInterface struct {
+	Name         string           `xml:"name,attr"`
+	Kind         string           `xml:"kind,attr"`
+	Parameters   []xmlParameter   `xml:"parameter"`
+	MemoryBlocks []xmlMemoryBlock `xml:"memoryBlock"`
+}
+
+func (i xmlInterface) parameter(name string) (string, bool) {
+	return findParameter(i.Parameters, name)
+}
+
+type xmlConnection struct {
+	Name       string         `xml:"name,attr"`
+	Kind       string         `xml:"kind,attr"`
+	Start      string         `xml:"start,attr"`
+	End        string         `xml:"end,attr"`
+	Parameters []xmlParameter `xml:"parameter"`
+}
+
+func (c xmlConnection) parameter(name string) (string, bool) {
+	return findParameter(c.Parameters, name)
+}
+
+type xmlParameter struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type xmlMemoryBlock struct {
+	Name string `xml:"name"`
+	Span string `xml:"span"`
+}
+
+func findParameter(params []xmlParameter, name string) (string, bool) {
+	for _, p := range params {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package sopc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Процессор видит ram.s1 через мост: cpu.data_master → bridge.s0,
+// bridge.m0 → ram.s1. Интерфейс spare.s1 ни к чему не подключён.
+const bridgedSystem = `<?xml version="1.0" encoding="UTF-8"?>
+<EnsembleReport name="bridged">
+ <module name="cpu" kind="cpu" path="cpu">
+  <interface name="data_master" kind="avalon_master"/>
+ </module>
+ <module name="bridge" kind="mm_bridge" path="bridge">
+  <interface name="s0" kind="avalon_slave">
+   <parameter name="addressSpan"><value>0x200000</value></parameter>
+  </interface>
+  <interface name="m0" kind="avalon_master"/>
+ </module>
+ <module name="ram" kind="onchip_memory" path="ram">
+  <interface name="s1" kind="avalon_slave">
+   <parameter name="addressSpan"><value>4096</value></parameter>
+  </interface>
+ </module>
+ <module name="spare" kind="onchip_memory" path="spare">
+  <interface name="s1" kind="avalon_slave">
+   <parameter name="addressSpan"><value>2048</value></parameter>
+  </interface>
+ </module>
+ <connection name="cpu.data_master/bridge.s0" kind="avalon" start="cpu.data_master" end="bridge.s0">
+  <parameter name="baseAddress"><value>0xff200000</value></parameter>
+ </connection>
+ <connection name="bridge.m0/ram.s1" kind="avalon" start="bridge.m0" end="ram.s1">
+  <parameter name="baseAddress"><value>0x4000</value></parameter>
+ </connection>
+</EnsembleReport>`
+
+func TestLookupThroughBridge(t *testing.T) {
+	sys, err := Parse(strings.NewReader(bridgedSystem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ram, err := sys.Lookup("ram.s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Region{Name: "ram.s1", Kind: "avalon_slave", Base: 0xff204000, Span: 4096, Master: "cpu.data_master", Mapped: true}
+	if ram != want {
+		t.Fatalf("ram.s1 = %+v, want %+v", ram, want)
+	}
+	if _, err := sys.Lookup("spare.s1"); !errors.Is(err, ErrUnmapped) {
+		t.Fatalf("Lookup(spare.s1) = %v, want ErrUnmapped", err)
+	}
+	if _, err := sys.Lookup("cpu.data_master"); err == nil {
+		t.Fatal("Lookup of a master succeeded")
+	}
+
+	regions := sys.Regions()
+	var names []string
+	for _, r := range regions {
+		names = append(names, r.Name)
+	}
+	if got := strings.Join(names, " "); got != "bridge.s0 ram.s1 spare.s1" {
+		t.Fatalf("regions %s", got)
+	}
+	if spare := regions[2]; spare.Mapped || spare.Span != 2048 {
+		t.Fatalf("spare.s1 = %+v, want unmapped with 2048 bytes", spare)
+	}
+}
+
+func TestLoadHPSSystem(t *testing.T) {
+	sys, err := Load("../../fpga_hpsmem/hps_system.sopcinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []Region{
+		{Name: "hps_0_bridges.axi_h2f_lw", Base: 0xff200000, Span: 0x200000},
+		{Name: "hps_0_uart0.axi_slave0", Base: 0xffc02000, Span: 256},
+	} {
+		got, err := sys.Lookup(want.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Base != want.Base || got.Span != want.Span || got.Master != "hps_0_arm_a9_0.altera_axi_master" {
+			t.Errorf("%s = %+v, want base %#x, span %d", want.Name, got, want.Base, want.Span)
+		}
+	}
+
+	var onchip Region
+	for _, r := range sys.Regions() {
+		if r.Name == "onchip_memory2_0.s1" {
+			onchip = r
+		}
+	}
+	if onchip.Name == "" || onchip.Mapped || onchip.Span != 4096 {
+		t.Errorf("onchip_memory2_0.s1 = %+v, want an unmapped 4096-byte region", onchip)
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SampleFile — отсчёты, прочитанные из файла SaveSample, SaveFrame
+// или SaveChannelFrame.
+type SampleFile struct {
+	Channels []string    // имена каналов из заголовка; для одноканальных файлов пусто
+	Start    time.Time   // время первого отсчёта, нулевое, если его нет в файле
+	Samples  [][]float64 // отсчёты каждого канала
+}
+
+// ReadSamples читает файл отсчётов: в строке время и значения каналов
+// (или одно значение без времени). Первая строка с нечисловыми значениями
+// считается заголовком.
+func ReadSamples(filename string) (*SampleFile, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("open csv failed: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	result := &SampleFile{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv failed: %w", err)
+		}
+		values := record
+		if len(record) > 1 {
+			values = record[1:]
+		}
+		if result.Samples == nil {
+			result.Samples = make([][]float64, len(values))
+		}
+		if len(values) != len(result.Samples) {
+			return nil, fmt.Errorf("%s:%d: got %d values, want %d", filename, line, len(values), len(result.Samples))
+		}
+
+		row := make([]float64, len(values))
+		header := false
+		for i, v := range values {
+			x, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				if line == 1 {
+					header = true
+					break
+				}
+				return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
+			}
+			row[i] = x
+		}
+		if header {
+			result.Channels = values
+			continue
+		}
+		if result.Start.IsZero() && len(record) > 1 {
+			// Время без разбора не мешает обработке: отсчёты равномерны.
+			result.Start, _ = time.Parse(time.RFC3339Nano, record[0])
+		}
+		for i, x := range row {
+			result.Samples[i] = append(result.Samples[i], x)
+		}
+	}
+	if len(result.Samples) == 0 || len(result.Samples[0]) == 0 {
+		return nil, fmt.Errorf("%s: no samples", filename)
+	}
+	return result, nil
+}
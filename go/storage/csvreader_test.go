@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fpga-ultrasound-go/memory"
+)
+
+func TestReadMultiChannelSamples(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "raw.csv")
+	frame := &memory.ChannelFrame{
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Channels:  memory.NewChannelLayout(2, memory.InterleaveSample).Channels,
+		Samples:   [][]float64{{1, 2, 3}, {-1, -2, -3}},
+	}
+	if err := SaveChannelFrame(name, frame, 1e6); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ReadSamples(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Channels) != 2 || stored.Channels[0] != frame.Channels[0].Name {
+		t.Errorf("channels %q", stored.Channels)
+	}
+	if !stored.Start.Equal(frame.Timestamp) {
+		t.Errorf("start %v, want %v", stored.Start, frame.Timestamp)
+	}
+	if len(stored.Samples) != 2 || stored.Samples[1][2] != -3 {
+		t.Errorf("samples %v", stored.Samples)
+	}
+}
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"fpga-ultrasound-go/memory"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -46,3 +49,195 @@ func SaveSpectrum(filename string, frequencies, spectrum []float64) error {
 	}
 	return nil
 }
+
+// SaveFrequencyResponse записывает частотную характеристику фильтра,
+// заменяя прежнее содержимое: частоту в Гц, АЧХ в дБ, развёрнутую фазу
+// в радианах и групповую задержку в отсчётах (NaN — не определена).
+func SaveFrequencyResponse(filename string, frequencies, magnitudeDB, phase, groupDelay []float64) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("open csv failed: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"frequency_hz", "magnitude_db", "phase_rad", "group_delay_samples"}); err != nil {
+		file.Close()
+		return fmt.Errorf("write csv failed: %w", err)
+	}
+	for i, f := range frequencies {
+		record := []string{
+			fmt.Sprintf("%.6f", f),
+			fmt.Sprintf("%.6f", magnitudeDB[i]),
+			fmt.Sprintf("%.6f", phase[i]),
+			fmt.Sprintf("%.6f", groupDelay[i]),
+		}
+		if err := writer.Write(record); err != nil {
+			file.Close()
+			return fmt.Errorf("write csv failed: %w", err)
+		}
+	}
+	return closeCSV(file, writer)
+}
+
+// closeCSV сбрасывает буфер writer и закрывает файл, возвращая ошибку
+// записи или закрытия.
+func closeCSV(file *os.File, writer *csv.Writer) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return fmt.Errorf("write csv failed: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close csv failed: %w", err)
+	}
+	return nil
+}
+
+// SaveFrame дописывает отсчёты кадра с временем захвата каждого отсчёта:
+// время кадра плюс i/sampleRate. Формат совпадает с SaveSample.
+func SaveFrame(filename string, frame *memory.Frame, sampleRate float64) error {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open csv failed: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+
+	step := 0.0
+	if sampleRate > 0 {
+		step = float64(time.Second) / sampleRate
+	}
+	for i, v := range frame.Samples {
+		sampleTime := frame.Timestamp.Add(time.Duration(float64(i) * step))
+		record := []string{sampleTime.UTC().Format(time.RFC3339Nano), fmt.Sprintf("%0.5f", v)}
+
+		if err := writer.Write(record); err != nil {
+			file.Close()
+			return fmt.Errorf("write csv failed: %w", err)
+		}
+	}
+
+	return closeCSV(file, writer)
+}
+
+// SaveChannelFrame дописывает многоканальный кадр: в строке время отсчёта
+// и значения всех каналов. Заголовок с именами каналов пишется при создании файла.
+func SaveChannelFrame(filename string, frame *memory.ChannelFrame, sampleRate float64) error {
+	_, statErr := os.Stat(filename)
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open csv failed: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+
+	if os.IsNotExist(statErr) {
+		header := []string{"timestamp"}
+		for _, ch := range frame.Channels {
+			header = append(header, ch.Name)
+		}
+		if err := writer.Write(header); err != nil {
+			file.Close()
+			return fmt.Errorf("write csv failed: %w", err)
+		}
+	}
+
+	step := 0.0
+	if sampleRate > 0 {
+		step = float64(time.Second) / sampleRate
+	}
+	record := make([]string, len(frame.Samples)+1)
+	for i := range frame.Samples[0] {
+		sampleTime := frame.Timestamp.Add(time.Duration(float64(i) * step))
+		record[0] = sampleTime.UTC().Format(time.RFC3339Nano)
+		for c, samples := range frame.Samples {
+			record[c+1] = fmt.Sprintf("%0.5f", samples[i])
+		}
+		if err := writer.Write(record); err != nil {
+			file.Close()
+			return fmt.Errorf("write csv failed: %w", err)
+		}
+	}
+	return closeCSV(file, writer)
+}
+
+// SaveFrameLog дописывает строку журнала кадров: номер, время захвата,
+// источник, аппаратный счётчик и накопленные счётчики пропусков.
+func SaveFrameLog(filename string, frame *memory.Frame, stats memory.FrameStats) error {
+	_, statErr := os.Stat(filename)
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open csv failed: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+
+	if os.IsNotExist(statErr) {
+		header := []string{"seq", "timestamp", "source", "counter", "dropped", "dropped_total", "repeated_total"}
+		if err := writer.Write(header); err != nil {
+			file.Close()
+			return fmt.Errorf("write csv failed: %w", err)
+		}
+	}
+
+	counter := ""
+	if frame.HasCounter {
+		counter = strconv.FormatUint(uint64(frame.Counter), 10)
+	}
+	record := []string{
+		strconv.FormatUint(frame.Seq, 10),
+		frame.Timestamp.UTC().Format(time.RFC3339Nano),
+		frame.SourceID,
+		counter,
+		strconv.FormatUint(frame.Dropped, 10),
+		strconv.FormatUint(stats.Dropped, 10),
+		strconv.FormatUint(stats.Repeated, 10),
+	}
+	if err := writer.Write(record); err != nil {
+		file.Close()
+		return fmt.Errorf("write csv failed: %w", err)
+	}
+	return closeCSV(file, writer)
+}
+
+// SaveJSON записывает v в файл в виде JSON с отступами, заменяя прежнее содержимое.
+func SaveJSON(filename string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode json failed: %w", err)
+	}
+	if err := os.WriteFile(filename, append(raw, '\n'), 0644); err != nil {
+		return fmt.Errorf("write json failed: %w", err)
+	}
+	return nil
+}
+
+// SaveTimeOfFlight дописывает строку результатов: номер первого кадра блока,
+// время его захвата, канал и время пролёта в секундах.
+func SaveTimeOfFlight(filename string, seq uint64, timestamp time.Time, channel string, tof float64) error {
+	_, statErr := os.Stat(filename)
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open csv failed: %w", err)
+	}
+
+	writer := csv.NewWriter(file)
+
+	if os.IsNotExist(statErr) {
+		if err := writer.Write([]string{"seq", "timestamp", "channel", "tof_s"}); err != nil {
+			file.Close()
+			return fmt.Errorf("write csv failed: %w", err)
+		}
+	}
+	record := []string{
+		strconv.FormatUint(seq, 10),
+		timestamp.UTC().Format(time.RFC3339Nano),
+		channel,
+		fmt.Sprintf("%.9f", tof),
+	}
+	if err := writer.Write(record); err != nil {
+		file.Close()
+		return fmt.Errorf("write csv failed: %w", err)
+	}
+	return closeCSV(file, writer)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Backpressure — поведение очереди блоков, когда обработка не успевает за сбором.
+type Backpressure string
+
+const (
+	// BackpressureBlock — сбор ждёт свободного места: блоки не теряются,
+	// но при долгой обработке FPGA может перезаписать непрочитанные кадры.
+	BackpressureBlock Backpressure = "block"
+	// BackpressureDropOldest — самый старый необработанный блок выбрасывается,
+	// обработка идёт по самым свежим данным.
+	BackpressureDropOldest Backpressure = "drop-oldest"
+)
+
+// block — отсчёты каналов, обрабатываемые за один проход.
+type block struct {
+	Seq       uint64      // номер первого кадра блока
+	Timestamp time.Time   // время захвата первого кадра
+	Frames    int         // число кадров в блоке
+	Samples   [][]float64 // отсчёты каждого канала
+}
+
+// Len возвращает число отсчётов одного канала.
+func (b *block) Len() int {
+	if len(b.Samples) == 0 {
+		return 0
+	}
+	return len(b.Samples[0])
+}
+
+// reset готовит блок к повторному использованию, сохраняя буферы.
+func (b *block) reset(channels int) {
+	b.Seq, b.Timestamp, b.Frames = 0, time.Time{}, 0
+	if cap(b.Samples) < channels {
+		b.Samples = make([][]float64, channels)
+	}
+	b.Samples = b.Samples[:channels]
+	for c := range b.Samples {
+		b.Samples[c] = b.Samples[c][:0]
+	}
+}
+
+// blockQueue — ограниченная очередь блоков между сбором и обработкой
+// со списком свободных блоков для повторного использования буферов.
+type blockQueue struct {
+	blocks  chan *block
+	free    chan *block
+	policy  Backpressure
+	dropped atomic.Uint64
+}
+
+// newBlockQueue создаёт очередь на depth блоков.
+func newBlockQueue(depth int, policy Backpressure) (*blockQueue, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("invalid queue depth %d", depth)
+	}
+	switch policy {
+	case BackpressureBlock, BackpressureDropOldest:
+	default:
+		return nil, fmt.Errorf("unknown backpressure policy %q, want block or drop-oldest", policy)
+	}
+	return &blockQueue{
+		blocks: make(chan *block, depth),
+		// Блоки в очереди, один в обработке и один в заполнении.
+		free:   make(chan *block, depth+2),
+		policy: policy,
+	}, nil
+}
+
+// get возвращает свободный блок для заполнения.
+func (q *blockQueue) get(channels int) *block {
+	var b *block
+	select {
+	case b = <-q.free:
+	default:
+		b = &block{}
+	}
+	b.reset(channels)
+	return b
+}
+
+// release возвращает обработанный блок в список свободных.
+func (q *blockQueue) release(b *block) {
+	select {
+	case q.free <- b:
+	default:
+	}
+}
+
+// put ставит блок в очередь по политике очереди. Для BackpressureBlock
+// ожидание прерывается отменой ctx.
+func (q *blockQueue) put(ctx context.Context, b *block) error {
+	if q.policy == BackpressureBlock {
+		select {
+		case q.blocks <- b:
+			return nil
+		case <-ctx.Done():
+			q.release(b)
+			return ctx.Err()
+		}
+	}
+	for {
+		select {
+		case q.blocks <- b:
+			return nil
+		default:
+		}
+		select {
+		case old := <-q.blocks:
+			q.dropped.Add(1)
+			q.release(old)
+		default:
+		}
+	}
+}
+
+// close сообщает обработке, что блоков больше не будет.
+func (q *blockQueue) close() {
+	close(q.blocks)
+}
+
+// Dropped возвращает число блоков, выброшенных политикой drop-oldest.
+func (q *blockQueue) Dropped() uint64 {
+	return q.dropped.Load()
+}
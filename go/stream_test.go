@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"fpga-ultrasound-go/memory"
+)
+
+func TestBlockQueueDropOldest(t *testing.T) {
+	q, err := newBlockQueue(2, BackpressureDropOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seq := uint64(1); seq <= 4; seq++ {
+		b := q.get(1)
+		b.Seq = seq
+		if err := q.put(context.Background(), b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if q.Dropped() != 2 {
+		t.Fatalf("dropped = %d, want 2", q.Dropped())
+	}
+	// В очереди остались самые свежие блоки.
+	for _, want := range []uint64{3, 4} {
+		if b := <-q.blocks; b.Seq != want {
+			t.Fatalf("block %d, want %d", b.Seq, want)
+		}
+	}
+}
+
+func TestBlockQueueBlockPolicy(t *testing.T) {
+	q, err := newBlockQueue(1, BackpressureBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.put(context.Background(), q.get(1)); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.put(ctx, q.get(1)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("put into a full queue = %v, want deadline exceeded", err)
+	}
+	if q.Dropped() != 0 {
+		t.Fatal("block policy dropped a block")
+	}
+}
+
+func TestBlockQueueReusesBuffers(t *testing.T) {
+	q, err := newBlockQueue(1, BackpressureBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := q.get(2)
+	b.Samples[0] = append(b.Samples[0], make([]float64, 1024)...)
+	b.Samples[1] = append(b.Samples[1], make([]float64, 1024)...)
+	first := &b.Samples[0][0]
+	q.release(b)
+
+	again := q.get(2)
+	if again.Len() != 0 || cap(again.Samples[0]) < 1024 || &again.Samples[0][:1][0] != first {
+		t.Fatal("released block was not reused")
+	}
+}
+
+func TestEmitAccumulatesShortFrames(t *testing.T) {
+	q, err := newBlockQueue(4, BackpressureBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pipeline{queue: q, blockSize: 250}
+	frame := []float64{0: 1, 99: 1}
+	for seq := uint64(1); seq <= 6; seq++ {
+		p.split.Samples = [][]float64{frame}
+		if err := p.emit(context.Background(), &memory.Frame{Seq: seq}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Из кадров по 100 отсчётов получаются блоки по 300: 1–3 и 4–6.
+	for _, want := range []uint64{1, 4} {
+		b := <-q.blocks
+		if b.Seq != want || b.Frames != 3 || b.Len() != 300 {
+			t.Fatalf("block seq %d frames %d len %d, want seq %d of 3 frames", b.Seq, b.Frames, b.Len(), want)
+		}
+	}
+}
+
+func TestFlushPartialBlock(t *testing.T) {
+	for _, tc := range []struct {
+		minBlock int
+		queued   bool
+	}{{101, true}, {300, false}} {
+		q, err := newBlockQueue(4, BackpressureBlock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := &pipeline{queue: q, blockSize: 300, minBlock: tc.minBlock}
+		for seq := uint64(1); seq <= 2; seq++ {
+			p.split.Samples = [][]float64{make([]float64, 100)}
+			if err := p.emit(context.Background(), &memory.Frame{Seq: seq}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		p.flush(context.Background())
+		if got := len(q.blocks) == 1; got != tc.queued {
+			t.Fatalf("minBlock %d: partial block queued = %t, want %t", tc.minBlock, got, tc.queued)
+		}
+		if p.cur != nil {
+			t.Fatal("flush left the partial block in place")
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package ultrasignal
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// ConvolutionMethod — способ вычисления причинной свёртки.
+type ConvolutionMethod int
+
+const (
+	// ConvolutionDirect — прямая сумма, O(n·k).
+	ConvolutionDirect ConvolutionMethod = iota
+	// ConvolutionOverlapAdd — БПФ по блокам с наложением и сложением хвостов.
+	ConvolutionOverlapAdd
+	// ConvolutionOverlapSave — БПФ по перекрывающимся блокам с отбрасыванием
+	// k-1 отсчётов циклического наложения.
+	ConvolutionOverlapSave
+)
+
+func (m ConvolutionMethod) String() string {
+	switch m {
+	case ConvolutionDirect:
+		return "direct"
+	case ConvolutionOverlapAdd:
+		return "overlap-add"
+	case ConvolutionOverlapSave:
+		return "overlap-save"
+	default:
+		return "unknown"
+	}
+}
+
+// Оценка стоимости для выбора метода, в умножениях с плавающей точкой:
+//
+//	прямая свёртка:  n·k
+//	БПФ блоками L:   ⌈n/(L-k+1)⌉ · (2·fftCost·L·log₂L + 4·L)
+//
+// Прямое и обратное БПФ на блок плюс поточечное комплексное умножение.
+// fftCost учитывает накладные расходы БПФ относительно простого умножения.
+const (
+	fftCost        = 2.5
+	minFFTKernel   = 16 // короче — прямая свёртка всегда быстрее
+	maxFFTSizeLog2 = 20
+)
+
+// ChooseConvolution выбирает самый дешёвый метод для сигнала длины n
+// и ядра длины k. Результаты методов совпадают с точностью округления.
+func ChooseConvolution(n, k int) ConvolutionMethod {
+	if k < minFFTKernel || n < k {
+		return ConvolutionDirect
+	}
+	size := fftSize(n, k)
+	if blockCost(n, k, size) < float64(n)*float64(k) {
+		return ConvolutionOverlapSave
+	}
+	return ConvolutionDirect
+}
+
+// fftSize подбирает длину БПФ (степень двойки, не меньше 2k) с наименьшей
+// оценкой стоимости свёртки n отсчётов с ядром длины k.
+func fftSize(n, k int) int {
+	best, bestCost := 0, math.Inf(1)
+	for size := nextPow2(2 * k); ; size *= 2 {
+		if c := blockCost(n, k, size); c < bestCost {
+			best, bestCost = size, c
+		}
+		if size >= n+k-1 || size >= 1<<maxFFTSizeLog2 {
+			return best
+		}
+	}
+}
+
+func blockCost(n, k, size int) float64 {
+	step := size - k + 1
+	blocks := (n + step - 1) / step
+	l := float64(size)
+	return float64(blocks) * (2*fftCost*l*math.Log2(l) + 4*l)
+}
+
+// nextPow2 возвращает наименьшую степень двойки, не меньшую n.
+func nextPow2(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
+
+// ConvolveAuto вычисляет ту же причинную свёртку, что и Convolve,
+// методом, выбранным ChooseConvolution.
+func ConvolveAuto(signal, kernel []float64) []float64 {
+	switch ChooseConvolution(len(signal), len(kernel)) {
+	case ConvolutionOverlapSave:
+		return ConvolveOverlapSave(signal, kernel)
+	default:
+		return Convolve(signal, kernel)
+	}
+}
+
+// ConvolveOverlapAdd вычисляет свёртку Convolve через БПФ методом
+// перекрытия со сложением.
+//
+// Сигнал делится на блоки длины B = L - k + 1, каждый дополняется нулями
+// до L, и линейная свёртка блока длины B + k - 1 = L получается
+// циклической через БПФ:
+//
+//	yₘ = IFFT( FFT(xₘ) · FFT(h) ) / L
+//
+// Хвосты соседних блоков (k-1 отсчётов) складываются. Выход обрезается
+// до длины сигнала, как в Convolve.
+func ConvolveOverlapAdd(signal, kernel []float64) []float64 {
+	n, k := len(signal), len(kernel)
+	output := make([]float64, n)
+	if n == 0 || k == 0 {
+		return output
+	}
+	c := newFFTConvolver(kernel, fftSize(n, k))
+	for start := 0; start < n; start += c.step {
+		seg := c.seg
+		m := copy(seg, signal[start:min(n, start+c.step)])
+		clear(seg[m:])
+		res := c.apply()
+		for i := 0; i < c.size && start+i < n; i++ {
+			output[start+i] += res[i]
+		}
+	}
+	return output
+}
+
+// ConvolveOverlapSave вычисляет свёртку Convolve через БПФ методом
+// перекрытия с отбрасыванием.
+//
+// Блоки входа длины L перекрываются на k-1 отсчётов; в циклической
+// свёртке блока первые k-1 отсчётов искажены наложением и отбрасываются,
+// остальные B = L - k + 1 совпадают с линейной свёрткой:
+//
+//	y[s+i] = IFFT( FFT(x[s-k+1 … s+B-1]) · FFT(h) )[k-1+i] / L,  i = 0…B-1
+//
+// Отсчёты до начала сигнала считаются нулевыми, как в Convolve.
+func ConvolveOverlapSave(signal, kernel []float64) []float64 {
+	n, k := len(signal), len(kernel)
+	output := make([]float64, n)
+	if n == 0 || k == 0 {
+		return output
+	}
+	ext := make([]float64, k-1+n)
+	copy(ext[k-1:], signal)
+	newFFTConvolver(kernel, fftSize(n, k)).save(output, ext)
+	return output
+}
+
+// fftConvolver хранит спектр ядра и рабочие буферы БПФ длины size.
+type fftConvolver struct {
+	fft      *fourier.FFT
+	size     int
+	step     int          // новых выходных отсчётов на блок: size - k + 1
+	k        int          // длина ядра
+	spectrum []complex128 // FFT(h) / size
+	coeffs   []complex128
+	seg      []float64
+	out      []float64
+}
+
+func newFFTConvolver(kernel []float64, size int) *fftConvolver {
+	k := len(kernel)
+	c := &fftConvolver{
+		fft:    fourier.NewFFT(size),
+		size:   size,
+		step:   size - k + 1,
+		k:      k,
+		coeffs: make([]complex128, size/2+1),
+		seg:    make([]float64, size),
+		out:    make([]float64, size),
+	}
+	copy(c.seg, kernel)
+	c.spectrum = c.fft.Coefficients(nil, c.seg)
+	// Нормировка обратного БПФ переносится в спектр ядра.
+	scale := complex(1/float64(size), 0)
+	for i := range c.spectrum {
+		c.spectrum[i] *= scale
+	}
+	return c
+}
+
+// apply возвращает циклическую свёртку seg с ядром. Результат действителен
+// до следующего вызова.
+func (c *fftConvolver) apply() []float64 {
+	c.fft.Coefficients(c.coeffs, c.seg)
+	for i := range c.coeffs {
+		c.coeffs[i] *= c.spectrum[i]
+	}
+	return c.fft.Sequence(c.out, c.coeffs)
+}
+
+// save заполняет dst методом перекрытия с отбрасыванием: ext — вход,
+// которому предшествуют k-1 отсчётов истории, len(dst) = len(ext) - (k-1).
+func (c *fftConvolver) save(dst, ext []float64) {
+	for start := 0; start < len(dst); start += c.step {
+		m := copy(c.seg, ext[start:])
+		clear(c.seg[m:])
+		res := c.apply()
+		copy(dst[start:min(len(dst), start+c.step)], res[c.k-1:])
+	}
+}
+
+// directValid заполняет dst прямой суммой по ext с историей из k-1 отсчётов:
+// dst[i] = Σⱼ ext[k-1+i-j]·h[j].
+func directValid(dst, ext, kernel []float64) {
+	k := len(kernel)
+	for i := range dst {
+		sum := 0.0
+		for j, h := range kernel {
+			sum += ext[k-1+i-j] * h
+		}
+		dst[i] = sum
+	}
+}
+
+// StreamConvolver выполняет причинную свёртку потока, поданного блоками
+// произвольной длины. Последние k-1 входных отсчётов сохраняются между
+// вызовами, поэтому склеенный выход совпадает с Convolve по всему потоку
+// (с точностью округления при БПФ).
+//
+// Метод для каждого блока выбирается ChooseConvolution; буферы и спектр
+// ядра переиспользуются, пока длина блоков не меняется.
+type StreamConvolver struct {
+	kernel []float64
+	ext    []float64 // история k-1 отсчётов, затем текущий блок
+	fft    *fftConvolver
+}
+
+// NewStreamConvolver создаёт свёртку потока с копией ядра kernel.
+func NewStreamConvolver(kernel []float64) *StreamConvolver {
+	k := len(kernel)
+	s := &StreamConvolver{kernel: append([]float64(nil), kernel...)}
+	s.ext = make([]float64, max(k-1, 0))
+	return s
+}
+
+// Process сворачивает очередной блок и дописывает len(block) выходных
+// отсчётов в dst, возвращая расширенный срез.
+func (s *StreamConvolver) Process(dst, block []float64) []float64 {
+	k := len(s.kernel)
+	start := len(dst)
+	dst = append(dst, make([]float64, len(block))...)
+	if k == 0 || len(block) == 0 {
+		return dst
+	}
+	s.ext = append(s.ext[:k-1], block...)
+	out := dst[start:]
+	if ChooseConvolution(len(block), k) == ConvolutionDirect {
+		directValid(out, s.ext, s.kernel)
+	} else {
+		size := fftSize(len(block), k)
+		if s.fft == nil || s.fft.size != size {
+			s.fft = newFFTConvolver(s.kernel, size)
+		}
+		s.fft.save(out, s.ext)
+	}
+	copy(s.ext, s.ext[len(s.ext)-(k-1):])
+	return dst
+}
+
+// Reset обнуляет историю, как перед началом нового потока.
+func (s *StreamConvolver) Reset() {
+	s.ext = s.ext[:max(len(s.kernel)-1, 0)]
+	clear(s.ext)
+}
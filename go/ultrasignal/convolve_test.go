@@ -0,0 +1,78 @@
+package ultrasignal
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func randomSignal(r *rand.Rand, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = r.NormFloat64()
+	}
+	return x
+}
+
+func assertClose(t *testing.T, name string, got, want []float64, tol float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: length %d, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > tol {
+			t.Fatalf("%s: [%d] = %g, want %g", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestFFTConvolutionMatchesDirect(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, tc := range []struct{ n, k int }{
+		{1, 1}, {5, 3}, {100, 101}, {1000, 101}, {4096, 33}, {3001, 257}, {10000, 1},
+	} {
+		x, h := randomSignal(r, tc.n), randomSignal(r, tc.k)
+		want := Convolve(x, h)
+		assertClose(t, "overlap-add", ConvolveOverlapAdd(x, h), want, 1e-9)
+		assertClose(t, "overlap-save", ConvolveOverlapSave(x, h), want, 1e-9)
+		assertClose(t, "auto", ConvolveAuto(x, h), want, 1e-9)
+	}
+	if got := ConvolveOverlapSave(nil, []float64{1}); len(got) != 0 {
+		t.Errorf("empty signal: %v", got)
+	}
+	if got := ConvolveOverlapAdd([]float64{1, 2}, nil); len(got) != 2 || got[0] != 0 || got[1] != 0 {
+		t.Errorf("empty kernel: %v", got)
+	}
+}
+
+func TestChooseConvolution(t *testing.T) {
+	if m := ChooseConvolution(1000, 5); m != ConvolutionDirect {
+		t.Errorf("short kernel: %s", m)
+	}
+	if m := ChooseConvolution(50, 101); m != ConvolutionDirect {
+		t.Errorf("signal shorter than kernel: %s", m)
+	}
+	if m := ChooseConvolution(1_000_000, 101); m != ConvolutionOverlapSave {
+		t.Errorf("long stream, 101 taps: %s", m)
+	}
+}
+
+func TestStreamConvolverMatchesConvolve(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	x, h := randomSignal(r, 20000), randomSignal(r, 101)
+	want := Convolve(x, h)
+
+	s := NewStreamConvolver(h)
+	var got []float64
+	for rest := x; len(rest) > 0; {
+		// Блоки от одного отсчёта до длины, при которой выбирается БПФ.
+		n := min(len(rest), 1+r.IntN(3000))
+		got = s.Process(got, rest[:n])
+		rest = rest[n:]
+	}
+	assertClose(t, "stream", got, want, 1e-9)
+
+	s.Reset()
+	again := s.Process(nil, x[:500])
+	assertClose(t, "after reset", again, want[:500], 1e-9)
+}
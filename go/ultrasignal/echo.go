@@ -1,6 +1,9 @@
 package ultrasignal
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
 // DetectEchoes находит временные координаты (индексы) эхо-сигналов в переданном сигнале.
 //
@@ -25,3 +28,10 @@ func DetectEchoes(signal []float64, threshold float64) []int {
 	}
 	return indices
 }
+
+// EchoesFrom возвращает индексы эха не раньше отсчёта from. Индексы
+// должны быть упорядочены по возрастанию, как их возвращает DetectEchoes.
+func EchoesFrom(indices []int, from float64) []int {
+	i := sort.Search(len(indices), func(i int) bool { return float64(indices[i]) >= from })
+	return indices[i:]
+}
@@ -101,6 +101,11 @@ func ComputeAnalyticSignal(signal []float64) []complex128 {
 		spectrum[i] *= 2
 	}
 
-	// Обратное преобразование Фурье — получаем аналитический сигнал
-	return fft.Sequence(nil, spectrum)
+	// Обратное преобразование Фурье — получаем аналитический сигнал.
+	// Sequence не нормирует результат, поэтому делим на n.
+	analytic := fft.Sequence(nil, spectrum)
+	for i := range analytic {
+		analytic[i] /= complex(float64(n), 0)
+	}
+	return analytic
 }
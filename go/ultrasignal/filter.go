@@ -3,24 +3,38 @@ package ultrasignal
 import (
 	"gonum.org/v1/gonum/floats"
 	"math"
+	"math/cmplx"
 )
 
 // BandPassFilter применяет фильтр (FIR kernel) к сигналу с помощью линейной свёртки.
 // Математически: y[n] = ∑ₖ x[n-k]·h[k]
 // где x[n] — входной сигнал, h[k] — импульсная характеристика (kernel), y[n] — отфильтрованный сигнал.
+// Для длинных сигналов свёртка выполняется через БПФ (см. ConvolveAuto).
+// Выход задержан на FIRDelay(kernel) отсчётов; без задержки — ApplyFIR.
 func BandPassFilter(input, kernel []float64) []float64 {
-	return Convolve(input, kernel)
+	return ConvolveAuto(input, kernel)
 }
 
-// ComputeAFC рассчитывает амплитудно-частотную характеристику (АЧХ) фильтра.
-// Использует DFT (FFT) ядра фильтра (kernel).
+// ComputeAFC рассчитывает амплитудно-частотную характеристику (АЧХ) фильтра
+// по его ядру (kernel), а не по отфильтрованному сигналу.
 //
-// Возвращает частоты и амплитуды для половины спектра (до Nyquist).
+// Возвращает частоты от 0 до Nyquist и |H(f)| на равномерной сетке
+// не менее чем из 257 точек (не реже шага f_s/(2k) для ядра длины k).
 //
-// Формула: H(f) = |FFT{h[n]}|
-// Нормировка: амплитуда делится на длину ядра и удваивается (кроме DC и Nyquist).
+// Формула: H(f) = Σ h[n]·e^{-j2πf·n/f_s}
+//
+// АЧХ в дБ, фаза и групповая задержка — FrequencyResponse.
 func ComputeAFC(kernel []float64, sampleRate float64) ([]float64, []float64) {
-	return ComputeFFT(kernel, sampleRate)
+	freqs, err := FrequencyGrid(GridLinear, 0, sampleRate/2, max(len(kernel), 256)+1)
+	if err != nil {
+		return nil, nil
+	}
+	response := FrequencyResponse(FIR(kernel), freqs, sampleRate)
+	magnitudes := make([]float64, len(freqs))
+	for i, h := range response.H {
+		magnitudes[i] = cmplx.Abs(h)
+	}
+	return freqs, magnitudes
 }
 
 // FIRBandPassKernel генерирует ядро КИХ-фильтра полосового пропускания
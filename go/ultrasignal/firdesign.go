@@ -0,0 +1,679 @@
+package ultrasignal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// MaxFIRTaps — наибольшая длина ядра при автоматическом подборе.
+const MaxFIRTaps = 4095
+
+// FIRSpec — требования к КИХ-фильтру с линейной фазой.
+type FIRSpec struct {
+	Type FilterType
+	// Edges — границы полос по возрастанию, Гц:
+	//
+	//	LowPass:        пропускание до Edges[0], задерживание от Edges[1]
+	//	HighPass:       задерживание до Edges[0], пропускание от Edges[1]
+	//	BandPass:       задерживание до Edges[0], пропускание Edges[1]…Edges[2], задерживание от Edges[3]
+	//	BandStop:       пропускание до Edges[0], задерживание Edges[1]…Edges[2], пропускание от Edges[3]
+	//	Differentiator: H = jω до Edges[0], задерживание от Edges[1];
+	//	                Edges[1] = f_s/2 — без полосы задерживания
+	//
+	// Ширина переходной полосы — расстояние между соседними границами.
+	Edges []float64
+	// PassRippleDB — допустимый размах неравномерности в полосе пропускания:
+	//
+	//	Rp = 20·log₁₀((1+δp)/(1-δp)),  δp — отклонение |H| от 1
+	//
+	// Для дифференциатора δp — отклонение |H| от ω, отнесённое к частоте
+	// границы полосы пропускания: |H(ω) - ω| ≤ δp·ω_p.
+	PassRippleDB float64
+	// StopDB — минимальное затухание в полосе задерживания: As = -20·log₁₀ δs.
+	StopDB float64
+	// Taps — длина ядра (нечётная); 0 — подобрать по спецификации.
+	Taps       int
+	SampleRate float64
+}
+
+// firBand — полоса в нормированных частотах [lo, hi], рад/отсчёт.
+type firBand struct {
+	lo, hi float64
+	pass   bool
+}
+
+// bands проверяет спецификацию и возвращает полосы пропускания и задерживания.
+func (s FIRSpec) bands() ([]firBand, error) {
+	if s.SampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %g Hz", s.SampleRate)
+	}
+	var want int
+	switch s.Type {
+	case LowPass, HighPass, Differentiator:
+		want = 2
+	case BandPass, BandStop:
+		want = 4
+	default:
+		return nil, fmt.Errorf("unknown FIR type %q, want lowpass, highpass, bandpass, bandstop or differentiator", s.Type)
+	}
+	if len(s.Edges) != want {
+		return nil, fmt.Errorf("%s needs %d band edges, got %d", s.Type, want, len(s.Edges))
+	}
+	nyquist := s.SampleRate / 2
+	w := make([]float64, want)
+	for i, f := range s.Edges {
+		last := i == want-1 && s.Type == Differentiator
+		if f <= 0 || f > nyquist || (f == nyquist && !last) || (i > 0 && f <= s.Edges[i-1]) {
+			return nil, fmt.Errorf("band edges %v Hz must increase within (0, %g)", s.Edges, nyquist)
+		}
+		w[i] = 2 * math.Pi * f / s.SampleRate
+	}
+	if s.PassRippleDB <= 0 {
+		return nil, fmt.Errorf("pass-band ripple must be positive, got %g dB", s.PassRippleDB)
+	}
+	if s.StopDB <= 0 && !(s.Type == Differentiator && s.Edges[1] == nyquist) {
+		return nil, fmt.Errorf("stop-band attenuation must be positive, got %g dB", s.StopDB)
+	}
+	if s.Taps < 0 || (s.Taps > 0 && (s.Taps%2 == 0 || s.Taps < 3)) {
+		return nil, fmt.Errorf("FIR length %d must be odd and at least 3", s.Taps)
+	}
+
+	switch s.Type {
+	case LowPass:
+		return []firBand{{0, w[0], true}, {w[1], math.Pi, false}}, nil
+	case HighPass:
+		return []firBand{{0, w[0], false}, {w[1], math.Pi, true}}, nil
+	case BandPass:
+		return []firBand{{0, w[0], false}, {w[1], w[2], true}, {w[3], math.Pi, false}}, nil
+	case BandStop:
+		return []firBand{{0, w[0], true}, {w[1], w[2], false}, {w[3], math.Pi, true}}, nil
+	default: // Differentiator
+		if w[1] == math.Pi {
+			return []firBand{{0, w[0], true}}, nil
+		}
+		return []firBand{{0, w[0], true}, {w[1], math.Pi, false}}, nil
+	}
+}
+
+// deviations переводит допуски из дБ в амплитуды δp и δs.
+func (s FIRSpec) deviations() (dp, ds float64) {
+	g := math.Pow(10, s.PassRippleDB/20)
+	dp = (g - 1) / (g + 1)
+	ds = math.Pow(10, -s.StopDB/20)
+	if s.StopDB <= 0 {
+		ds = dp // дифференциатор без полосы задерживания
+	}
+	return dp, ds
+}
+
+// transition возвращает самую узкую переходную полосу, рад/отсчёт.
+// У дифференциатора без полосы задерживания переход — до частоты
+// Найквиста, где ядро нечётной длины с антисимметрией обращается в нуль.
+func transition(bands []firBand) float64 {
+	if len(bands) == 1 {
+		return math.Pi - bands[0].hi
+	}
+	width := math.Inf(1)
+	for i := 1; i < len(bands); i++ {
+		width = math.Min(width, bands[i].lo-bands[i-1].hi)
+	}
+	return width
+}
+
+// DesignKaiser рассчитывает ядро оконным методом с окном Кайзера.
+//
+// Затухание A = -20·log₁₀ min(δp, δs) задаёт параметр окна
+//
+//	β = 0.1102·(A - 8.7),                          A > 50
+//	β = 0.5842·(A - 21)^0.4 + 0.07886·(A - 21),    21 ≤ A ≤ 50
+//	β = 0,                                         A < 21
+//
+// и длину N = (A - 7.95)/(2.285·Δω) + 1, где Δω — самая узкая переходная
+// полоса. Идеальная характеристика обрезается посередине переходных полос.
+// Если Taps не задан, длина подбирается около оценки (см. fitTaps).
+func DesignKaiser(spec FIRSpec) ([]float64, error) {
+	bands, err := spec.bands()
+	if err != nil {
+		return nil, err
+	}
+	dp, ds := spec.deviations()
+	atten := -20 * math.Log10(math.Min(dp, ds))
+	beta := kaiserBeta(atten)
+	width := transition(bands)
+	taps := 0
+	if atten > 21 {
+		taps = int(math.Ceil((atten-7.95)/(2.285*width))) + 1
+	} else {
+		taps = int(math.Ceil(5.79/width)) + 1
+	}
+	return fitTaps(spec, taps, func(n int) ([]float64, error) {
+		kernel := idealKernel(spec.Type, bands, n)
+		for i, w := range KaiserWindow(n, beta) {
+			kernel[i] *= w
+		}
+		return kernel, nil
+	})
+}
+
+func kaiserBeta(atten float64) float64 {
+	switch {
+	case atten > 50:
+		return 0.1102 * (atten - 8.7)
+	case atten >= 21:
+		return 0.5842*math.Pow(atten-21, 0.4) + 0.07886*(atten-21)
+	default:
+		return 0
+	}
+}
+
+// KaiserWindow возвращает окно Кайзера длины n:
+//
+//	w[i] = I₀(β·√(1 - (2i/(n-1) - 1)²)) / I₀(β)
+func KaiserWindow(n int, beta float64) []float64 {
+	w := make([]float64, n)
+	if n == 1 {
+		w[0] = 1
+		return w
+	}
+	norm := besselI0(beta)
+	for i := range w {
+		r := 2*float64(i)/float64(n-1) - 1
+		w[i] = besselI0(beta*math.Sqrt(math.Max(1-r*r, 0))) / norm
+	}
+	return w
+}
+
+// besselI0 — модифицированная функция Бесселя нулевого порядка (ряд):
+//
+//	I₀(x) = Σₖ ((x/2)ᵏ / k!)²
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > 1e-17*sum; k++ {
+		t := x / (2 * float64(k))
+		term *= t * t
+		sum += term
+	}
+	return sum
+}
+
+// idealKernel — идеальная характеристика, обрезанная до n отсчётов
+// (n нечётное, центр m = 0). Для кусочно-постоянной АЧХ — сумма sinc
+// по полосам пропускания, границы которых лежат посередине переходов:
+//
+//	h[m] = Σ (sin(ω₂m) - sin(ω₁m)) / (πm),   h[0] = Σ (ω₂ - ω₁)/π
+//
+// Для дифференциатора с H = jω до ω_c:
+//
+//	h[m] = (ω_c·cos(ω_c·m)/m - sin(ω_c·m)/m²) / π,   h[0] = 0
+//
+// Без полосы задерживания ω_c — середина перехода до π: при ω_c = π
+// пьедестал окна Кайзера искажает наклон у нуля на w[0] ≈ 1/I₀(β).
+func idealKernel(typ FilterType, bands []firBand, n int) []float64 {
+	edges := []float64{0} // границы идеальных полос: 0, середины переходов, π
+	for i := 1; i < len(bands); i++ {
+		edges = append(edges, (bands[i-1].hi+bands[i].lo)/2)
+	}
+	edges = append(edges, math.Pi)
+	var pass [][2]float64
+	for i, b := range bands {
+		if b.pass {
+			pass = append(pass, [2]float64{edges[i], edges[i+1]})
+		}
+	}
+
+	kernel := make([]float64, n)
+	half := n / 2
+	for i := range kernel {
+		m := float64(i - half)
+		if typ == Differentiator {
+			wc := pass[0][1]
+			if len(bands) == 1 {
+				wc = (bands[0].hi + math.Pi) / 2
+			}
+			if m != 0 {
+				kernel[i] = (wc*math.Cos(wc*m)/m - math.Sin(wc*m)/(m*m)) / math.Pi
+			}
+			continue
+		}
+		for _, p := range pass {
+			if m == 0 {
+				kernel[i] += (p[1] - p[0]) / math.Pi
+			} else {
+				kernel[i] += (math.Sin(p[1]*m) - math.Sin(p[0]*m)) / (math.Pi * m)
+			}
+		}
+	}
+	return kernel
+}
+
+// fitTaps рассчитывает ядро длины spec.Taps, а если она не задана —
+// ищет около оценки estimate короткое ядро, которое укладывается в
+// спецификацию. От оценки шаг удваивается вверх или вниз, пока не найдётся
+// пара длин «не подходит — подходит», затем промежуток делится пополам.
+// Неравномерность не обязана монотонно убывать с длиной, поэтому
+// найденная длина не всегда наименьшая, но каждое ядро проверено VerifyFIR.
+func fitTaps(spec FIRSpec, estimate int, design func(n int) ([]float64, error)) ([]float64, error) {
+	if spec.Taps > 0 {
+		return design(spec.Taps)
+	}
+	tried := make(map[int][]float64) // подходящие ядра; nil — длина не подходит
+	fits := func(n int) (bool, error) {
+		if kernel, ok := tried[n]; ok {
+			return kernel != nil, nil
+		}
+		kernel, err := design(n)
+		if err != nil {
+			return false, err
+		}
+		if _, err := VerifyFIR(kernel, spec); err != nil {
+			kernel = nil
+		}
+		tried[n] = kernel
+		return kernel != nil, nil
+	}
+
+	// lo не подходит (или равна 1), hi подходит; обе нечётные.
+	n := min(max(estimate, 3)|1, MaxFIRTaps)
+	ok, err := fits(n)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := 1, n
+	if ok {
+		for step := 2; hi > 3; step *= 2 {
+			n = max(hi-step, 3)
+			if ok, err = fits(n); err != nil {
+				return nil, err
+			}
+			if !ok {
+				lo = n
+				break
+			}
+			hi = n
+		}
+	} else {
+		lo = n
+		for step := 2; ; step *= 2 {
+			if lo == MaxFIRTaps {
+				return nil, fmt.Errorf("%s spec is not met with up to %d taps", spec.Type, MaxFIRTaps)
+			}
+			n = min(lo+step, MaxFIRTaps)
+			if ok, err = fits(n); err != nil {
+				return nil, err
+			}
+			if ok {
+				hi = n
+				break
+			}
+			lo = n
+		}
+	}
+	for hi-lo > 2 {
+		mid := (lo+hi)/2 | 1
+		if ok, err := fits(mid); err != nil {
+			return nil, err
+		} else if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return tried[hi], nil
+}
+
+// Параметры алгоритма Ремеза: плотность сетки на одну базисную функцию,
+// предел итераций обмена и относительный допуск сходимости.
+const (
+	remezDensity   = 16
+	remezMaxIter   = 250
+	remezTolerance = 1e-9
+)
+
+// DesignRemez рассчитывает равноволновое (минимаксное) ядро алгоритмом
+// Паркса–Макклеллана.
+//
+// Ядро нечётной длины N = 2L+1 с симметрией (тип I) имеет амплитуду
+//
+//	A(ω) = Σ_{k=0}^{L} aₖ·cos(kω) = P(cos ω)
+//
+// с антисимметрией (тип III, дифференциатор) — A(ω) = sin ω·P(cos ω).
+// Алгоритм обмена Ремеза ищет многочлен P, при котором взвешенная ошибка
+// W(ω)·(D(ω) - A(ω)) на полосах чередует знак с одинаковым модулем δ.
+// Веса полос задаются отношением δp/δs, у дифференциатора ошибка
+// в полосе пропускания отнесена к частоте её границы (W = 1/ω_p).
+//
+// Если Taps не задан, длина оценивается по формуле Кайзера
+//
+//	N = (-20·log₁₀√(δp·δs) - 13) / (14.6·Δω/2π) + 1
+//
+// и уточняется перебором около оценки (см. fitTaps).
+func DesignRemez(spec FIRSpec) ([]float64, error) {
+	bands, err := spec.bands()
+	if err != nil {
+		return nil, err
+	}
+	dp, ds := spec.deviations()
+	width := transition(bands) / (2 * math.Pi)
+	estimate := int(math.Ceil((-20*math.Log10(math.Sqrt(dp*ds))-13)/(14.6*width))) + 1
+
+	return fitTaps(spec, estimate, func(n int) ([]float64, error) {
+		return remezKernel(spec.Type, bands, dp/ds, n)
+	})
+}
+
+// remezPoint — точка сетки: x = cos ω, требуемая амплитуда и вес (для
+// типа III уже поделённые и умноженные на sin ω) и номер полосы.
+type remezPoint struct {
+	x, desired, weight float64
+	band               int
+}
+
+func remezKernel(typ FilterType, bands []firBand, stopWeight float64, n int) ([]float64, error) {
+	antisym := typ == Differentiator
+	half := n / 2
+	r := half + 1 // число базисных функций P
+	if antisym {
+		r = half
+	}
+	grid := remezGrid(typ, bands, stopWeight, r)
+	if len(grid) < r+1 {
+		return nil, fmt.Errorf("exchange grid has %d points for %d taps", len(grid), n)
+	}
+	poly := remezExchange(grid, r)
+
+	amplitude := func(omega float64) float64 {
+		a := poly(math.Cos(omega))
+		if antisym {
+			a *= math.Sin(omega)
+		}
+		return a
+	}
+	// Отсчёты ядра — обратное ДПФ амплитуды на N равномерных частотах.
+	kernel := make([]float64, n)
+	samples := make([]float64, half+1)
+	for m := range samples {
+		samples[m] = amplitude(2 * math.Pi * float64(m) / float64(n))
+	}
+	for k := 0; k <= half; k++ {
+		var g float64
+		if antisym {
+			for m := 1; m <= half; m++ {
+				g -= 2 * samples[m] * math.Sin(2*math.Pi*float64(m*k)/float64(n))
+			}
+			g /= float64(n)
+			kernel[half+k], kernel[half-k] = g, -g
+			continue
+		}
+		g = samples[0]
+		for m := 1; m <= half; m++ {
+			g += 2 * samples[m] * math.Cos(2*math.Pi*float64(m*k)/float64(n))
+		}
+		g /= float64(n)
+		kernel[half+k], kernel[half-k] = g, g
+	}
+	return kernel, nil
+}
+
+// remezGrid строит плотную сетку по полосам с шагом π/(16·r).
+// Для типа III точки ω = 0 и ω = π, где sin ω = 0, исключаются.
+func remezGrid(typ FilterType, bands []firBand, stopWeight float64, r int) []remezPoint {
+	antisym := typ == Differentiator
+	step := math.Pi / float64(remezDensity*r)
+	var grid []remezPoint
+	for bi, b := range bands {
+		lo, hi := b.lo, b.hi
+		if antisym && lo == 0 {
+			lo += step
+		}
+		if antisym && hi == math.Pi {
+			hi -= step
+		}
+		count := max(int(math.Ceil((hi-lo)/step)), 1)
+		for i := 0; i <= count; i++ {
+			omega := lo + (hi-lo)*float64(i)/float64(count)
+			p := remezPoint{x: math.Cos(omega), band: bi}
+			switch {
+			case !b.pass:
+				p.desired, p.weight = 0, stopWeight
+			case typ == Differentiator:
+				p.desired, p.weight = omega, 1/bands[0].hi
+			default:
+				p.desired, p.weight = 1, 1
+			}
+			if antisym {
+				// D/sin ω и W·sin ω; у дифференциатора D/sin ω → 1 при ω → 0.
+				s := math.Sin(omega)
+				if typ == Differentiator && b.pass {
+					p.desired, p.weight = 1/sinc(omega), p.weight*s
+				} else {
+					p.desired, p.weight = p.desired/s, p.weight*s
+				}
+			}
+			grid = append(grid, p)
+		}
+	}
+	return grid
+}
+
+// sinc возвращает sin ω / ω.
+func sinc(omega float64) float64 {
+	if omega == 0 {
+		return 1
+	}
+	return math.Sin(omega) / omega
+}
+
+// remezExchange выполняет обмен Ремеза для многочлена степени r-1
+// и возвращает его как функцию от x = cos ω.
+func remezExchange(grid []remezPoint, r int) func(x float64) float64 {
+	ext := make([]int, r+1)
+	for i := range ext {
+		ext[i] = i * (len(grid) - 1) / r
+	}
+	errs := make([]float64, len(grid))
+	var poly func(float64) float64
+	for range remezMaxIter {
+		xs := make([]float64, r+1)
+		for i, j := range ext {
+			xs[i] = grid[j].x
+		}
+		// Уклонение δ, при котором интерполянт проходит через
+		// D(xᵢ) - (-1)ⁱ·δ/W(xᵢ) во всех r+1 точках.
+		bw := baryWeights(xs)
+		var num, den float64
+		sign := 1.0
+		for i, j := range ext {
+			num += bw[i] * grid[j].desired
+			den += sign * bw[i] / grid[j].weight
+			sign = -sign
+		}
+		delta := num / den
+
+		ys := make([]float64, r)
+		sign = 1
+		for i := range ys {
+			p := grid[ext[i]]
+			ys[i] = p.desired - sign*delta/p.weight
+			sign = -sign
+		}
+		poly = barycentric(xs[:r], ys, baryWeights(xs[:r]))
+
+		peak := 0.0
+		for i, p := range grid {
+			errs[i] = p.weight * (p.desired - poly(p.x))
+			peak = math.Max(peak, math.Abs(errs[i]))
+		}
+		next := remezExtrema(grid, errs, math.Abs(delta), r)
+		if next == nil || equalInts(next, ext) || peak-math.Abs(delta) <= remezTolerance*peak {
+			break
+		}
+		ext = next
+	}
+	return poly
+}
+
+// baryWeights — веса барицентрической интерполяции 1/Πⱼ≠ᵢ(xᵢ - xⱼ),
+// масштабированные общим множителем, чтобы избежать переполнения.
+func baryWeights(xs []float64) []float64 {
+	logs := make([]float64, len(xs))
+	signs := make([]float64, len(xs))
+	minLog := math.Inf(1)
+	for i := range xs {
+		signs[i] = 1
+		for j := range xs {
+			if i == j {
+				continue
+			}
+			d := xs[i] - xs[j]
+			logs[i] += math.Log(math.Abs(d))
+			if d < 0 {
+				signs[i] = -signs[i]
+			}
+		}
+		minLog = math.Min(minLog, logs[i])
+	}
+	w := make([]float64, len(xs))
+	for i := range w {
+		w[i] = signs[i] * math.Exp(minLog-logs[i])
+	}
+	return w
+}
+
+// barycentric возвращает интерполяционный многочлен через точки (xs, ys):
+//
+//	P(x) = Σ wᵢyᵢ/(x - xᵢ) / Σ wᵢ/(x - xᵢ)
+func barycentric(xs, ys, w []float64) func(float64) float64 {
+	return func(x float64) float64 {
+		var num, den float64
+		for i, xi := range xs {
+			if x == xi {
+				return ys[i]
+			}
+			c := w[i] / (x - xi)
+			num += c * ys[i]
+			den += c
+		}
+		return num / den
+	}
+}
+
+// remezExtrema выбирает новые r+1 точек чередования: локальные экстремумы
+// ошибки внутри полос (включая края) с модулем не меньше δ; из соседних
+// экстремумов одного знака остаётся больший, лишние отбрасываются с того
+// конца, где ошибка меньше. nil — точек недостаточно.
+func remezExtrema(grid []remezPoint, errs []float64, delta float64, r int) []int {
+	var ext []int
+	for i, e := range errs {
+		// Ошибка в точках чередования равна δ с точностью интерполяции.
+		if math.Abs(e) < delta*(1-1e-6) || e == 0 {
+			continue
+		}
+		s := math.Copysign(1, e)
+		if i > 0 && grid[i-1].band == grid[i].band && s*errs[i-1] > s*e {
+			continue
+		}
+		if i+1 < len(grid) && grid[i+1].band == grid[i].band && s*errs[i+1] > s*e {
+			continue
+		}
+		if n := len(ext); n > 0 && math.Signbit(errs[ext[n-1]]) == math.Signbit(e) {
+			if math.Abs(e) > math.Abs(errs[ext[n-1]]) {
+				ext[n-1] = i
+			}
+			continue
+		}
+		ext = append(ext, i)
+	}
+	for len(ext) > r+1 {
+		if math.Abs(errs[ext[0]]) < math.Abs(errs[ext[len(ext)-1]]) {
+			ext = ext[1:]
+		} else {
+			ext = ext[:len(ext)-1]
+		}
+	}
+	if len(ext) < r+1 {
+		return nil
+	}
+	return ext
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// FIRCheck — достигнутые показатели ядра по сравнению со спецификацией.
+type FIRCheck struct {
+	Taps         int
+	PassRippleDB float64 // размах неравномерности в полосе пропускания, дБ
+	StopDB       float64 // наименьшее затухание в полосе задерживания, дБ; +Inf — полосы нет
+}
+
+// VerifyFIR вычисляет АЧХ ядра на плотной сетке (БПФ с дополнением нулями
+// и точные значения на границах полос) и сравнивает её со спецификацией.
+// Ошибка содержит все нарушения; FIRCheck заполнен при любой корректной
+// спецификации. Поле spec.Taps не проверяется.
+func VerifyFIR(kernel []float64, spec FIRSpec) (FIRCheck, error) {
+	spec.Taps = 0
+	bands, err := spec.bands()
+	if err != nil {
+		return FIRCheck{}, err
+	}
+	size := nextPow2(max(16*len(kernel), 4096))
+	padded := make([]float64, size)
+	copy(padded, kernel)
+	coeffs := fourier.NewFFT(size).Coefficients(nil, padded)
+
+	var passDev, stopPeak float64
+	check := func(omega, mag float64) {
+		for _, b := range bands {
+			if omega < b.lo || omega > b.hi {
+				continue
+			}
+			switch {
+			case !b.pass:
+				stopPeak = math.Max(stopPeak, mag)
+			case spec.Type == Differentiator:
+				passDev = math.Max(passDev, math.Abs(mag-omega)/bands[0].hi)
+			default:
+				passDev = math.Max(passDev, math.Abs(mag-1))
+			}
+		}
+	}
+	for k, c := range coeffs {
+		check(2*math.Pi*float64(k)/float64(size), cmplx.Abs(c))
+	}
+	for _, b := range bands {
+		for _, omega := range []float64{b.lo, b.hi} {
+			check(omega, cmplx.Abs(FIR(kernel).Response(omega)))
+		}
+	}
+
+	res := FIRCheck{Taps: len(kernel), PassRippleDB: math.Inf(1), StopDB: math.Inf(1)}
+	if passDev < 1 {
+		res.PassRippleDB = 20 * math.Log10((1+passDev)/(1-passDev))
+	}
+	if stopPeak > 0 {
+		res.StopDB = -20 * math.Log10(stopPeak)
+	}
+	// Допуск на округление при расчёте АЧХ.
+	const slackDB = 1e-9
+	var errs []error
+	if res.PassRippleDB > spec.PassRippleDB+slackDB {
+		errs = append(errs, fmt.Errorf("pass-band ripple %.4g dB exceeds %.4g dB", res.PassRippleDB, spec.PassRippleDB))
+	}
+	if len(bands) > 1 && res.StopDB < spec.StopDB-slackDB {
+		errs = append(errs, fmt.Errorf("stop-band attenuation %.4g dB is below %.4g dB", res.StopDB, spec.StopDB))
+	}
+	return res, errors.Join(errs...)
+}
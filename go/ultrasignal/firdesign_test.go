@@ -0,0 +1,128 @@
+package ultrasignal
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+var firSpecs = []FIRSpec{
+	{Type: LowPass, Edges: []float64{1e6, 1.5e6}, PassRippleDB: 0.1, StopDB: 60},
+	{Type: HighPass, Edges: []float64{1e6, 1.5e6}, PassRippleDB: 0.1, StopDB: 60},
+	{Type: BandPass, Edges: []float64{0.3e6, 0.6e6, 1.4e6, 1.7e6}, PassRippleDB: 0.5, StopDB: 50},
+	{Type: BandStop, Edges: []float64{1e6, 1.5e6, 2e6, 2.5e6}, PassRippleDB: 0.5, StopDB: 40},
+	{Type: Differentiator, Edges: []float64{2e6, 5e6}, PassRippleDB: 0.1},
+	{Type: Differentiator, Edges: []float64{1e6, 2e6}, PassRippleDB: 0.2, StopDB: 40},
+	{Type: Differentiator, Edges: []float64{1e6, 1.5e6}, PassRippleDB: 0.1, StopDB: 40},
+	{Type: Differentiator, Edges: []float64{4e6, 5e6}, PassRippleDB: 0.1},
+}
+
+func TestDesignFIRMeetsSpec(t *testing.T) {
+	for _, spec := range firSpecs {
+		spec.SampleRate = 10e6
+		taps := make(map[string]int)
+		for _, d := range []struct {
+			name   string
+			design func(FIRSpec) ([]float64, error)
+		}{{"kaiser", DesignKaiser}, {"remez", DesignRemez}} {
+			kernel, err := d.design(spec)
+			if err != nil {
+				t.Errorf("%s %s: %v", d.name, spec.Type, err)
+				continue
+			}
+			if len(kernel)%2 == 0 || !IsLinearPhase(kernel, 1e-9) {
+				t.Errorf("%s %s: %d taps, linear phase %v", d.name, spec.Type, len(kernel), IsLinearPhase(kernel, 1e-9))
+			}
+			if check, err := VerifyFIR(kernel, spec); err != nil {
+				t.Errorf("%s %s: %+v: %v", d.name, spec.Type, check, err)
+			}
+			taps[d.name] = len(kernel)
+		}
+		// Равноволновое ядро короче оконного при той же спецификации.
+		if taps["remez"] > taps["kaiser"] {
+			t.Errorf("%s: remez %d taps, kaiser %d", spec.Type, taps["remez"], taps["kaiser"])
+		}
+	}
+}
+
+func TestDesignRemezEquiripple(t *testing.T) {
+	spec := FIRSpec{Type: LowPass, Edges: []float64{1e6, 1.5e6}, PassRippleDB: 1, StopDB: 40, Taps: 41, SampleRate: 10e6}
+	kernel, err := DesignRemez(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kernel) != 41 {
+		t.Fatalf("got %d taps, want 41", len(kernel))
+	}
+	// При минимаксном решении отклонения в полосах относятся как веса.
+	var passDev, stopPeak float64
+	for f := 0.0; f <= 5e6; f += 1e3 {
+		mag := cmplx.Abs(FIR(kernel).Response(2 * math.Pi * f / spec.SampleRate))
+		switch {
+		case f <= 1e6:
+			passDev = math.Max(passDev, math.Abs(mag-1))
+		case f >= 1.5e6:
+			stopPeak = math.Max(stopPeak, mag)
+		}
+	}
+	dp, ds := spec.deviations()
+	if ratio := passDev / stopPeak; math.Abs(ratio/(dp/ds)-1) > 0.01 {
+		t.Errorf("pass/stop deviation ratio %g, want %g", ratio, dp/ds)
+	}
+}
+
+func TestDifferentiatorSlope(t *testing.T) {
+	spec := FIRSpec{Type: Differentiator, Edges: []float64{2e6, 5e6}, PassRippleDB: 0.01, SampleRate: 10e6}
+	kernel, err := DesignRemez(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Производная по номеру отсчёта: sin(ωn) → ω·cos(ω(n - L)).
+	const omega = 0.7
+	x := make([]float64, 400)
+	for n := range x {
+		x[n] = math.Sin(omega * float64(n))
+	}
+	y := Convolve(x, kernel)
+	half := len(kernel) / 2
+	for n := len(kernel); n < len(x); n++ {
+		want := omega * math.Cos(omega*float64(n-half))
+		if math.Abs(y[n]-want) > 2e-3*omega {
+			t.Fatalf("y[%d] = %g, want %g", n, y[n], want)
+		}
+	}
+}
+
+func TestVerifyFIR(t *testing.T) {
+	spec := FIRSpec{Type: BandPass, Edges: []float64{0.3e6, 0.6e6, 1.4e6, 1.7e6}, PassRippleDB: 0.5, StopDB: 50, SampleRate: 10e6}
+	short := spec
+	short.Taps = 31
+	kernel, err := DesignKaiser(short)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check, err := VerifyFIR(kernel, spec)
+	if err == nil {
+		t.Fatalf("31-tap kernel passed %+v", check)
+	}
+	if check.Taps != 31 || check.StopDB >= 50 || check.StopDB <= 0 {
+		t.Errorf("check %+v", check)
+	}
+
+	for _, bad := range []FIRSpec{
+		{Type: LowPass, Edges: []float64{1e6}, PassRippleDB: 1, StopDB: 40, SampleRate: 10e6},
+		{Type: LowPass, Edges: []float64{2e6, 1e6}, PassRippleDB: 1, StopDB: 40, SampleRate: 10e6},
+		{Type: HighPass, Edges: []float64{1e6, 5e6}, PassRippleDB: 1, StopDB: 40, SampleRate: 10e6},
+		{Type: LowPass, Edges: []float64{1e6, 2e6}, StopDB: 40, SampleRate: 10e6},
+		{Type: LowPass, Edges: []float64{1e6, 2e6}, PassRippleDB: 1, SampleRate: 10e6},
+		{Type: LowPass, Edges: []float64{1e6, 2e6}, PassRippleDB: 1, StopDB: 40, Taps: 20, SampleRate: 10e6},
+		{Type: "allpass", Edges: []float64{1e6, 2e6}, PassRippleDB: 1, StopDB: 40, SampleRate: 10e6},
+	} {
+		if _, err := DesignRemez(bad); err == nil {
+			t.Errorf("DesignRemez(%+v) succeeded", bad)
+		}
+		if _, err := DesignKaiser(bad); err == nil {
+			t.Errorf("DesignKaiser(%+v) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,597 @@
+package ultrasignal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// FilterType — вид частотной избирательности фильтра.
+type FilterType string
+
+const (
+	LowPass  FilterType = "lowpass"
+	HighPass FilterType = "highpass"
+	BandPass FilterType = "bandpass"
+	BandStop FilterType = "bandstop"
+	// Differentiator — H = jω в полосе пропускания; только для КИХ (DesignRemez, DesignKaiser).
+	Differentiator FilterType = "differentiator"
+)
+
+// IIRFamily — аналоговый прототип БИХ-фильтра.
+type IIRFamily string
+
+const (
+	// Butterworth — максимально плоская АЧХ, -3 дБ на частоте среза.
+	Butterworth IIRFamily = "butterworth"
+	// Chebyshev1 — равноволновая неравномерность RippleDB в полосе пропускания,
+	// на частоте среза усиление -RippleDB.
+	Chebyshev1 IIRFamily = "chebyshev1"
+	// Chebyshev2 — плоская полоса пропускания и затухание не меньше StopDB
+	// в полосе задерживания; частота среза — её граница.
+	Chebyshev2 IIRFamily = "chebyshev2"
+	// Bessel — максимально плоская групповая задержка (минимум искажений
+	// формы импульса), -3 дБ на частоте среза.
+	Bessel IIRFamily = "bessel"
+)
+
+// MaxIIROrder — наибольший порядок прототипа. Выше него корни полинома
+// Бесселя и произведения полюсов теряют точность в float64.
+const MaxIIROrder = 20
+
+// IIRSpec — требования к БИХ-фильтру.
+type IIRSpec struct {
+	Family IIRFamily
+	Type   FilterType
+	// Order — порядок НЧ-прототипа; полосовой и режекторный фильтры имеют
+	// вдвое больший порядок.
+	Order      int
+	CutoffHz   float64 // частота среза LowPass и HighPass
+	LowHz      float64 // границы полосы BandPass и BandStop
+	HighHz     float64
+	RippleDB   float64 // Chebyshev1: неравномерность в полосе пропускания, дБ
+	StopDB     float64 // Chebyshev2: затухание в полосе задерживания, дБ
+	SampleRate float64
+}
+
+// Biquad — звено второго порядка (a₀ = 1):
+//
+//	H(z) = (B0 + B1·z⁻¹ + B2·z⁻²) / (1 + A1·z⁻¹ + A2·z⁻²)
+type Biquad struct {
+	B0, B1, B2 float64
+	A1, A2     float64
+}
+
+// SOS — каскад звеньев второго порядка; передаточная функция фильтра —
+// произведение передаточных функций звеньев.
+type SOS []Biquad
+
+// zpk — нули, полюсы и коэффициент усиления передаточной функции.
+type zpk struct {
+	z, p []complex128
+	k    float64
+}
+
+// DesignIIR рассчитывает цифровой БИХ-фильтр по спецификации: аналоговый
+// прототип с частотой среза 1 рад/с, преобразование частоты к заданным
+// границам (с предыскажением tan), билинейное преобразование
+//
+//	s = 2f_s·(z - 1)/(z + 1)
+//
+// и разбиение на звенья второго порядка.
+func DesignIIR(spec IIRSpec) (SOS, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	var proto zpk
+	switch spec.Family {
+	case Butterworth:
+		proto = butterworthPrototype(spec.Order)
+	case Chebyshev1:
+		proto = chebyshev1Prototype(spec.Order, spec.RippleDB)
+	case Chebyshev2:
+		proto = chebyshev2Prototype(spec.Order, spec.StopDB)
+	case Bessel:
+		proto = besselPrototype(spec.Order)
+	}
+
+	fs := spec.SampleRate
+	warp := func(f float64) float64 { return 2 * fs * math.Tan(math.Pi*f/fs) }
+	var analog zpk
+	switch spec.Type {
+	case LowPass:
+		analog = proto.lowPass(warp(spec.CutoffHz))
+	case HighPass:
+		analog = proto.highPass(warp(spec.CutoffHz))
+	case BandPass, BandStop:
+		w1, w2 := warp(spec.LowHz), warp(spec.HighHz)
+		wo, bw := math.Sqrt(w1*w2), w2-w1
+		if spec.Type == BandPass {
+			analog = proto.bandPass(wo, bw)
+		} else {
+			analog = proto.bandStop(wo, bw)
+		}
+	}
+	return analog.bilinear(fs).sections(), nil
+}
+
+func (s IIRSpec) validate() error {
+	var errs []error
+	if s.Order < 1 || s.Order > MaxIIROrder {
+		errs = append(errs, fmt.Errorf("filter order %d is outside 1…%d", s.Order, MaxIIROrder))
+	}
+	if s.SampleRate <= 0 {
+		errs = append(errs, fmt.Errorf("invalid sample rate %g Hz", s.SampleRate))
+	}
+	nyquist := s.SampleRate / 2
+	inBand := func(f float64) bool { return f > 0 && f < nyquist }
+	switch s.Type {
+	case LowPass, HighPass:
+		if !inBand(s.CutoffHz) {
+			errs = append(errs, fmt.Errorf("cutoff %g Hz is outside (0, %g)", s.CutoffHz, nyquist))
+		}
+	case BandPass, BandStop:
+		if !inBand(s.LowHz) || !inBand(s.HighHz) || s.LowHz >= s.HighHz {
+			errs = append(errs, fmt.Errorf("band %g–%g Hz is invalid for Nyquist %g Hz", s.LowHz, s.HighHz, nyquist))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown filter type %q", s.Type))
+	}
+	switch s.Family {
+	case Butterworth, Bessel:
+	case Chebyshev1:
+		if s.RippleDB <= 0 {
+			errs = append(errs, fmt.Errorf("chebyshev1 needs a positive pass-band ripple, got %g dB", s.RippleDB))
+		}
+	case Chebyshev2:
+		if s.StopDB <= 0 {
+			errs = append(errs, fmt.Errorf("chebyshev2 needs a positive stop-band attenuation, got %g dB", s.StopDB))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown IIR family %q", s.Family))
+	}
+	return errors.Join(errs...)
+}
+
+// protoAngles возвращает углы π·m/(2N) для m = -N+1, -N+3, …, N-1.
+func protoAngles(n int) []float64 {
+	angles := make([]float64, n)
+	for i := range angles {
+		angles[i] = math.Pi * float64(2*i-n+1) / float64(2*n)
+	}
+	return angles
+}
+
+// butterworthPrototype: полюсы на единичной окружности
+//
+//	pₘ = -exp(jπm/(2N)),  усиление 1.
+func butterworthPrototype(n int) zpk {
+	p := make([]complex128, n)
+	for i, theta := range protoAngles(n) {
+		p[i] = -cmplx.Exp(complex(0, theta))
+	}
+	return zpk{p: p, k: 1}
+}
+
+// chebyshev1Prototype: полюсы на эллипсе
+//
+//	ε = √(10^(Rp/10) - 1),  μ = asinh(1/ε)/N,  pₘ = -sinh(μ + jπm/(2N))
+//
+// Для чётного N усиление на нулевой частоте равно 1/√(1+ε²).
+func chebyshev1Prototype(n int, rippleDB float64) zpk {
+	eps := math.Sqrt(math.Pow(10, rippleDB/10) - 1)
+	mu := math.Asinh(1/eps) / float64(n)
+	p := make([]complex128, n)
+	for i, theta := range protoAngles(n) {
+		p[i] = -cmplx.Sinh(complex(mu, theta))
+	}
+	k := real(prodNeg(p))
+	if n%2 == 0 {
+		k /= math.Sqrt(1 + eps*eps)
+	}
+	return zpk{p: p, k: k}
+}
+
+// chebyshev2Prototype: нули на мнимой оси и полюсы, обратные полюсам
+// Чебышёва I-го рода с δ = 1/√(10^(Rs/10) - 1):
+//
+//	zₘ = j / sin(πm/(2N)),  m ≠ 0
+//	pₘ = 1 / (sinh(μ)·Re qₘ + j·cosh(μ)·Im qₘ),  qₘ = -exp(jπm/(2N)),  μ = asinh(1/δ)/N
+func chebyshev2Prototype(n int, stopDB float64) zpk {
+	delta := 1 / math.Sqrt(math.Pow(10, stopDB/10)-1)
+	mu := math.Asinh(1/delta) / float64(n)
+	var z []complex128
+	p := make([]complex128, n)
+	for i, theta := range protoAngles(n) {
+		if 2*i-n+1 != 0 {
+			z = append(z, complex(0, 1/math.Sin(theta)))
+		}
+		q := -cmplx.Exp(complex(0, theta))
+		p[i] = 1 / complex(math.Sinh(mu)*real(q), math.Cosh(mu)*imag(q))
+	}
+	k := real(prodNeg(p) / prodNeg(z))
+	return zpk{z: z, p: p, k: k}
+}
+
+// besselPrototype: полюсы — корни обратного полинома Бесселя
+//
+//	θ_N(s) = Σₖ aₖ·sᵏ,  aₖ = (2N-k)! / (2^(N-k)·k!·(N-k)!)
+//
+// (групповая задержка 1 с), масштабированные так, что на 1 рад/с
+// усиление -3 дБ; усиление на нулевой частоте 1.
+func besselPrototype(n int) zpk {
+	coeffs := make([]float64, n+1) // coeffs[k] при sᵏ, старший равен 1
+	for k := 0; k <= n; k++ {
+		// Через логарифмы факториалов: для больших N они не помещаются в float64.
+		lg := lgamma(2*n-k+1) - float64(n-k)*math.Ln2 - lgamma(k+1) - lgamma(n-k+1)
+		coeffs[k] = math.Exp(lg)
+	}
+	p := polyRoots(coeffs)
+	k := real(prodNeg(p))
+	// Частота -3 дБ монотонной АЧХ ищется делением отрезка пополам.
+	mag2 := func(w float64) float64 {
+		h := complex(k, 0)
+		for _, pole := range p {
+			h /= complex(0, w) - pole
+		}
+		return real(h * cmplx.Conj(h))
+	}
+	lo, hi := 0.0, 1.0
+	for mag2(hi) > 0.5 {
+		hi *= 2
+	}
+	for range 100 {
+		mid := (lo + hi) / 2
+		if mag2(mid) > 0.5 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	for i := range p {
+		p[i] /= complex(lo, 0)
+	}
+	return zpk{p: p, k: real(prodNeg(p))}
+}
+
+func lgamma(n int) float64 {
+	v, _ := math.Lgamma(float64(n))
+	return v
+}
+
+// polyRoots находит корни приведённого полинома Σ c[k]·sᵏ (c[n] = 1)
+// методом Дюрана–Кернера.
+func polyRoots(c []float64) []complex128 {
+	n := len(c) - 1
+	eval := func(s complex128) complex128 {
+		v := complex(c[n], 0)
+		for k := n - 1; k >= 0; k-- {
+			v = v*s + complex(c[k], 0)
+		}
+		return v
+	}
+	// Начальные приближения на окружности радиуса, оценивающего модули корней.
+	radius := math.Pow(math.Abs(c[0]), 1/float64(n))
+	roots := make([]complex128, n)
+	for i := range roots {
+		roots[i] = cmplx.Rect(radius, 2*math.Pi*float64(i)/float64(n)+0.4)
+	}
+	for range 1000 {
+		change := 0.0
+		for i := range roots {
+			den := complex(1, 0)
+			for j := range roots {
+				if i != j {
+					den *= roots[i] - roots[j]
+				}
+			}
+			delta := eval(roots[i]) / den
+			roots[i] -= delta
+			change = math.Max(change, cmplx.Abs(delta)/math.Max(cmplx.Abs(roots[i]), 1))
+		}
+		if change < 1e-15 {
+			break
+		}
+	}
+	return roots
+}
+
+// prodNeg возвращает Π(-xᵢ).
+func prodNeg(x []complex128) complex128 {
+	v := complex(1, 0)
+	for _, xi := range x {
+		v *= -xi
+	}
+	return v
+}
+
+// degree — превышение числа полюсов над числом нулей.
+func (f zpk) degree() int {
+	return len(f.p) - len(f.z)
+}
+
+func scaleRoots(x []complex128, s complex128) []complex128 {
+	out := make([]complex128, len(x))
+	for i, xi := range x {
+		out[i] = xi * s
+	}
+	return out
+}
+
+func invertRoots(x []complex128, s complex128) []complex128 {
+	out := make([]complex128, len(x))
+	for i, xi := range x {
+		out[i] = s / xi
+	}
+	return out
+}
+
+// lowPass переносит срез прототипа на wo рад/с: s → s/wo.
+func (f zpk) lowPass(wo float64) zpk {
+	return zpk{
+		z: scaleRoots(f.z, complex(wo, 0)),
+		p: scaleRoots(f.p, complex(wo, 0)),
+		k: f.k * math.Pow(wo, float64(f.degree())),
+	}
+}
+
+// highPass: s → wo/s; недостающие нули уходят в s = 0.
+func (f zpk) highPass(wo float64) zpk {
+	z := invertRoots(f.z, complex(wo, 0))
+	z = append(z, make([]complex128, f.degree())...)
+	return zpk{
+		z: z,
+		p: invertRoots(f.p, complex(wo, 0)),
+		k: f.k * real(prodNeg(f.z)/prodNeg(f.p)),
+	}
+}
+
+// bandPass: s → (s² + wo²)/(s·bw); каждый корень r даёт пару
+// r·bw/2 ± √((r·bw/2)² - wo²), недостающие нули — в s = 0.
+func (f zpk) bandPass(wo, bw float64) zpk {
+	z := splitRoots(scaleRoots(f.z, complex(bw/2, 0)), wo)
+	z = append(z, make([]complex128, f.degree())...)
+	return zpk{
+		z: z,
+		p: splitRoots(scaleRoots(f.p, complex(bw/2, 0)), wo),
+		k: f.k * math.Pow(bw, float64(f.degree())),
+	}
+}
+
+// bandStop: s → s·bw/(s² + wo²); недостающие нули — в ±j·wo.
+func (f zpk) bandStop(wo, bw float64) zpk {
+	z := splitRoots(invertRoots(f.z, complex(bw/2, 0)), wo)
+	for range f.degree() {
+		z = append(z, complex(0, wo), complex(0, -wo))
+	}
+	return zpk{
+		z: z,
+		p: splitRoots(invertRoots(f.p, complex(bw/2, 0)), wo),
+		k: f.k * real(prodNeg(f.z)/prodNeg(f.p)),
+	}
+}
+
+// splitRoots заменяет каждый корень r парой r ± √(r² - wo²).
+func splitRoots(x []complex128, wo float64) []complex128 {
+	out := make([]complex128, 0, 2*len(x))
+	for _, r := range x {
+		d := cmplx.Sqrt(r*r - complex(wo*wo, 0))
+		out = append(out, r+d, r-d)
+	}
+	return out
+}
+
+// bilinear переводит аналоговый фильтр в цифровой:
+//
+//	z = (2f_s + s)/(2f_s - s),  k_d = k·Re(Π(2f_s - zᵢ)/Π(2f_s - pᵢ))
+//
+// Нули на бесконечности переходят в z = -1 (частота Найквиста).
+func (f zpk) bilinear(fs float64) zpk {
+	fs2 := complex(2*fs, 0)
+	num, den := complex(1, 0), complex(1, 0)
+	z := make([]complex128, 0, len(f.p))
+	for _, zi := range f.z {
+		z = append(z, (fs2+zi)/(fs2-zi))
+		num *= fs2 - zi
+	}
+	for range f.degree() {
+		z = append(z, -1)
+	}
+	p := make([]complex128, len(f.p))
+	for i, pi := range f.p {
+		p[i] = (fs2 + pi) / (fs2 - pi)
+		den *= fs2 - pi
+	}
+	return zpk{z: z, p: p, k: f.k * real(num/den)}
+}
+
+// conjTol — допуск, с которым корень считается вещественным.
+const conjTol = 1e-9
+
+// splitConj делит корни на вещественные и представителей комплексно-
+// сопряжённых пар (с положительной мнимой частью).
+func splitConj(x []complex128) (reals []float64, pairs []complex128) {
+	for _, r := range x {
+		switch {
+		case math.Abs(imag(r)) <= conjTol*math.Max(cmplx.Abs(r), 1):
+			reals = append(reals, real(r))
+		case imag(r) > 0:
+			pairs = append(pairs, r)
+		}
+	}
+	return reals, pairs
+}
+
+// sections разбивает цифровой фильтр на звенья второго порядка.
+//
+// Полюсы берутся по удалению от единичной окружности: к полюсу (паре или
+// двум вещественным) подбираются ближайшие нули. Звенья с полюсами ближе
+// всего к окружности (наибольшим усилением) ставятся в конец каскада,
+// а общий коэффициент усиления — в первое звено, что уменьшает
+// переполнение и шум округления.
+func (f zpk) sections() SOS {
+	zr, zc := splitConj(f.z)
+	pr, pc := splitConj(f.p)
+	sort.Slice(pr, func(i, j int) bool { return math.Abs(pr[i]) < math.Abs(pr[j]) })
+
+	var sos SOS
+	for len(pr) > 0 || len(pc) > 0 {
+		var sec Biquad
+		var poles int
+		// Полюс, ближайший к окружности: последний вещественный или пара.
+		useReal := len(pc) == 0
+		if !useReal && len(pr) > 0 {
+			iPair := closestToCircle(pc)
+			useReal = 1-math.Abs(pr[len(pr)-1]) < 1-cmplx.Abs(pc[iPair])
+		}
+		var anchor complex128
+		if useReal {
+			p1 := pr[len(pr)-1]
+			pr = pr[:len(pr)-1]
+			anchor = complex(p1, 0)
+			sec.A1, poles = -p1, 1
+			if len(pr) > 0 {
+				p2 := pr[len(pr)-1]
+				pr = pr[:len(pr)-1]
+				sec.A1, sec.A2, poles = -(p1 + p2), p1*p2, 2
+			}
+		} else {
+			i := closestToCircle(pc)
+			p := pc[i]
+			pc = append(pc[:i], pc[i+1:]...)
+			anchor = p
+			sec.A1, sec.A2, poles = -2*real(p), real(p)*real(p)+imag(p)*imag(p), 2
+		}
+
+		// Нули: ближайшая к полюсу пара или вещественные, сколько полюсов.
+		iz := nearestRoot(zc, anchor)
+		ir := nearestReal(zr, anchor)
+		takePair := poles == 2 && iz >= 0 &&
+			(len(zr) < 2 || ir < 0 || cmplx.Abs(zc[iz]-anchor) < cmplx.Abs(complex(zr[ir], 0)-anchor))
+		switch {
+		case takePair:
+			z := zc[iz]
+			zc = append(zc[:iz], zc[iz+1:]...)
+			sec.B0, sec.B1, sec.B2 = 1, -2*real(z), real(z)*real(z)+imag(z)*imag(z)
+		default:
+			sec.B0 = 1
+			var taken []float64
+			for range poles {
+				if j := nearestReal(zr, anchor); j >= 0 {
+					taken = append(taken, zr[j])
+					zr = append(zr[:j], zr[j+1:]...)
+				}
+			}
+			switch len(taken) {
+			case 1:
+				sec.B1 = -taken[0]
+			case 2:
+				sec.B1, sec.B2 = -(taken[0] + taken[1]), taken[0]*taken[1]
+			}
+		}
+		sos = append(sos, sec)
+	}
+	// Оставшиеся нули (если нулей больше, чем полюсов) — в отдельных звеньях.
+	for len(zr) > 0 || len(zc) > 0 {
+		sec := Biquad{B0: 1}
+		if len(zc) > 0 {
+			z := zc[len(zc)-1]
+			zc = zc[:len(zc)-1]
+			sec.B1, sec.B2 = -2*real(z), real(z)*real(z)+imag(z)*imag(z)
+		} else {
+			sec.B1 = -zr[len(zr)-1]
+			zr = zr[:len(zr)-1]
+		}
+		sos = append(sos, sec)
+	}
+
+	// Звенья выбирались от окружности внутрь; каскад — в обратном порядке.
+	for i, j := 0, len(sos)-1; i < j; i, j = i+1, j-1 {
+		sos[i], sos[j] = sos[j], sos[i]
+	}
+	if len(sos) == 0 {
+		sos = SOS{{B0: 1}}
+	}
+	sos[0].B0 *= f.k
+	sos[0].B1 *= f.k
+	sos[0].B2 *= f.k
+	return sos
+}
+
+func closestToCircle(x []complex128) int {
+	best := 0
+	for i, r := range x {
+		if 1-cmplx.Abs(r) < 1-cmplx.Abs(x[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func nearestRoot(x []complex128, to complex128) int {
+	best := -1
+	for i, r := range x {
+		if best < 0 || cmplx.Abs(r-to) < cmplx.Abs(x[best]-to) {
+			best = i
+		}
+	}
+	return best
+}
+
+func nearestReal(x []float64, to complex128) int {
+	best := -1
+	for i, r := range x {
+		if best < 0 || cmplx.Abs(complex(r, 0)-to) < cmplx.Abs(complex(x[best], 0)-to) {
+			best = i
+		}
+	}
+	return best
+}
+
+// SOSFilter — фильтрация потока каскадом звеньев второго порядка
+// (транспонированная прямая форма II). Состояние звеньев сохраняется
+// между вызовами, поэтому поток можно подавать по отсчёту или блоками
+// произвольной длины.
+type SOSFilter struct {
+	sos   SOS
+	state [][2]float64
+}
+
+// NewSOSFilter создаёт фильтр с нулевым начальным состоянием.
+func NewSOSFilter(sos SOS) *SOSFilter {
+	return &SOSFilter{sos: append(SOS(nil), sos...), state: make([][2]float64, len(sos))}
+}
+
+// Step фильтрует один отсчёт:
+//
+//	y = b₀x + s₁,  s₁ ← b₁x - a₁y + s₂,  s₂ ← b₂x - a₂y
+func (f *SOSFilter) Step(x float64) float64 {
+	for i, b := range f.sos {
+		s := &f.state[i]
+		y := b.B0*x + s[0]
+		s[0] = b.B1*x - b.A1*y + s[1]
+		s[1] = b.B2*x - b.A2*y
+		x = y
+	}
+	return x
+}
+
+// Process фильтрует блок и дописывает результат в dst, возвращая расширенный срез.
+func (f *SOSFilter) Process(dst, block []float64) []float64 {
+	for _, x := range block {
+		dst = append(dst, f.Step(x))
+	}
+	return dst
+}
+
+// Reset обнуляет состояние звеньев.
+func (f *SOSFilter) Reset() {
+	clear(f.state)
+}
+
+// FilterSOS фильтрует сигнал целиком с нулевым начальным состоянием.
+func FilterSOS(sos SOS, signal []float64) []float64 {
+	return NewSOSFilter(sos).Process(make([]float64, 0, len(signal)), signal)
+}
@@ -0,0 +1,157 @@
+package ultrasignal
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
+	"testing"
+)
+
+// gainDB возвращает усиление каскада на частоте f, дБ.
+func gainDB(sos SOS, f, sampleRate float64) float64 {
+	return 20 * math.Log10(cmplx.Abs(sos.Response(2*math.Pi*f/sampleRate)))
+}
+
+func TestDesignIIRButterworthCoefficients(t *testing.T) {
+	// Эталон: scipy.signal.butter(2, 0.2) — срез 0.1 f_s.
+	sos, err := DesignIIR(IIRSpec{Family: Butterworth, Type: LowPass, Order: 2, CutoffHz: 100, SampleRate: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sos) != 1 {
+		t.Fatalf("got %d sections, want 1", len(sos))
+	}
+	want := Biquad{B0: 0.06745527, B1: 0.13491055, B2: 0.06745527, A1: -1.1429805, A2: 0.4128016}
+	got := sos[0]
+	for _, c := range [][2]float64{{got.B0, want.B0}, {got.B1, want.B1}, {got.B2, want.B2}, {got.A1, want.A1}, {got.A2, want.A2}} {
+		if math.Abs(c[0]-c[1]) > 1e-7 {
+			t.Fatalf("section %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestDesignIIRResponse(t *testing.T) {
+	const fs = 10e6
+	for _, tc := range []struct {
+		name string
+		spec IIRSpec
+		// Пары (частота, ожидаемое усиление в дБ) с допуском tol.
+		points [][2]float64
+		tol    float64
+	}{
+		{"butterworth lowpass", IIRSpec{Family: Butterworth, Type: LowPass, Order: 5, CutoffHz: 1e6},
+			[][2]float64{{0, 0}, {1e6, -3.0103}}, 1e-6},
+		{"butterworth highpass", IIRSpec{Family: Butterworth, Type: HighPass, Order: 4, CutoffHz: 1e6},
+			[][2]float64{{fs / 2, 0}, {1e6, -3.0103}}, 1e-6},
+		{"butterworth bandpass", IIRSpec{Family: Butterworth, Type: BandPass, Order: 3, LowHz: 0.5e6, HighHz: 1.5e6},
+			[][2]float64{{0.5e6, -3.0103}, {1.5e6, -3.0103}}, 1e-6},
+		{"chebyshev1 lowpass", IIRSpec{Family: Chebyshev1, Type: LowPass, Order: 4, CutoffHz: 1e6, RippleDB: 1},
+			[][2]float64{{0, -1}, {1e6, -1}}, 1e-6},
+		{"chebyshev1 bandstop", IIRSpec{Family: Chebyshev1, Type: BandStop, Order: 3, LowHz: 1e6, HighHz: 2e6, RippleDB: 0.5},
+			[][2]float64{{0, 0}, {1e6, -0.5}, {2e6, -0.5}}, 1e-6},
+		{"chebyshev2 lowpass", IIRSpec{Family: Chebyshev2, Type: LowPass, Order: 5, CutoffHz: 1e6, StopDB: 40},
+			[][2]float64{{0, 0}, {1e6, -40}}, 1e-6},
+		{"bessel lowpass", IIRSpec{Family: Bessel, Type: LowPass, Order: 6, CutoffHz: 1e6},
+			[][2]float64{{0, 0}, {1e6, -3.0103}}, 1e-3},
+		{"bessel highpass", IIRSpec{Family: Bessel, Type: HighPass, Order: 3, CutoffHz: 2e6},
+			[][2]float64{{fs / 2, 0}, {2e6, -3.0103}}, 1e-3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.spec.SampleRate = fs
+			sos, err := DesignIIR(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, pt := range tc.points {
+				if got := gainDB(sos, pt[0], fs); math.Abs(got-pt[1]) > tc.tol {
+					t.Errorf("gain at %g Hz = %.6f dB, want %g", pt[0], got, pt[1])
+				}
+			}
+			for _, b := range sos {
+				// Устойчивость: полюсы звена внутри единичной окружности.
+				if math.Abs(b.A2) >= 1 || math.Abs(b.A1) >= 1+b.A2 {
+					t.Errorf("unstable section %+v", b)
+				}
+			}
+		})
+	}
+}
+
+func TestDesignIIRStopBand(t *testing.T) {
+	const fs = 10e6
+	cheb2, err := DesignIIR(IIRSpec{Family: Chebyshev2, Type: BandPass, Order: 4, LowHz: 1e6, HighHz: 2e6, StopDB: 50, SampleRate: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notch, err := DesignIIR(IIRSpec{Family: Butterworth, Type: BandStop, Order: 2, LowHz: 1.9e6, HighHz: 2.1e6, SampleRate: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for f := 0.0; f <= fs/2; f += 10e3 {
+		if (f <= 1e6 || f >= 2e6) && gainDB(cheb2, f, fs) > -50+1e-6 {
+			t.Fatalf("chebyshev2 gain at %g Hz = %g dB, want ≤ -50", f, gainDB(cheb2, f, fs))
+		}
+	}
+	deepest := 0.0
+	for f := 1.9e6; f <= 2.1e6; f += 1e3 {
+		deepest = math.Min(deepest, gainDB(notch, f, fs))
+	}
+	if deepest > -60 || math.Abs(gainDB(notch, 0, fs)) > 1e-9 {
+		t.Errorf("notch depth %g dB, DC gain %g dB", deepest, gainDB(notch, 0, fs))
+	}
+}
+
+func TestDesignIIRInvalid(t *testing.T) {
+	for _, spec := range []IIRSpec{
+		{Family: Butterworth, Type: LowPass, Order: 0, CutoffHz: 1, SampleRate: 10},
+		{Family: Butterworth, Type: LowPass, Order: MaxIIROrder + 1, CutoffHz: 1, SampleRate: 10},
+		{Family: Butterworth, Type: LowPass, Order: 2, CutoffHz: 5, SampleRate: 10},
+		{Family: Butterworth, Type: BandPass, Order: 2, LowHz: 3, HighHz: 2, SampleRate: 10},
+		{Family: Chebyshev1, Type: LowPass, Order: 2, CutoffHz: 1, SampleRate: 10},
+		{Family: Chebyshev2, Type: LowPass, Order: 2, CutoffHz: 1, SampleRate: 10},
+		{Family: "elliptic", Type: LowPass, Order: 2, CutoffHz: 1, SampleRate: 10},
+		{Family: Butterworth, Type: "allpass", Order: 2, CutoffHz: 1, SampleRate: 10},
+	} {
+		if _, err := DesignIIR(spec); err == nil {
+			t.Errorf("DesignIIR(%+v) succeeded", spec)
+		}
+	}
+}
+
+func TestSOSFilterStreaming(t *testing.T) {
+	const fs = 10e6
+	sos, err := DesignIIR(IIRSpec{Family: Butterworth, Type: BandPass, Order: 3, LowHz: 0.5e6, HighHz: 1.5e6, SampleRate: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := randomSignal(rand.New(rand.NewPCG(7, 8)), 5000)
+	want := FilterSOS(sos, x)
+
+	f := NewSOSFilter(sos)
+	var got []float64
+	for start, size := 0, 1; start < len(x); start, size = start+size, size*2+1 {
+		got = f.Process(got, x[start:min(len(x), start+size)])
+	}
+	assertClose(t, "blocks", got, want, 1e-12)
+
+	f.Reset()
+	for i, v := range x[:100] {
+		if y := f.Step(v); y != want[i] {
+			t.Fatalf("sample %d after reset: %g, want %g", i, y, want[i])
+		}
+	}
+
+	// Тон в полосе пропускания проходит с единичной амплитудой.
+	tone := make([]float64, 4000)
+	for i := range tone {
+		tone[i] = math.Sin(2 * math.Pi * 0.866e6 * float64(i) / fs)
+	}
+	out := FilterSOS(sos, tone)
+	peak := 0.0
+	for _, v := range out[2000:] {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	if math.Abs(peak-1) > 0.01 {
+		t.Errorf("pass-band tone amplitude %g, want 1", peak)
+	}
+}
@@ -0,0 +1,205 @@
+package ultrasignal
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// FilterPhase — способ применения КИХ-фильтра относительно времени.
+type FilterPhase string
+
+const (
+	// PhaseCausal — причинная свёртка (Convolve): выход задержан на
+	// групповую задержку ядра, для симметричного ядра на (k-1)/2 отсчётов.
+	PhaseCausal FilterPhase = "causal"
+	// PhaseSame — свёртка "same": выход сдвинут на целую часть групповой
+	// задержки, для нечётного симметричного ядра задержка нулевая.
+	PhaseSame FilterPhase = "same"
+	// PhaseZero — прямой и обратный проход (FiltFilt): нулевая фаза для
+	// любого ядра, АЧХ возводится в квадрат.
+	PhaseZero FilterPhase = "zero-phase"
+)
+
+// FilteredSignal — результат фильтрации с задержкой относительно входа.
+// Задержка вычитается при расчёте времени пролёта, поэтому оно не зависит
+// от способа фильтрации.
+type FilteredSignal struct {
+	Samples []float64
+	Delay   float64 // задержка выхода относительно входа, отсчёты (может быть дробной)
+}
+
+// ApplyFIR фильтрует сигнал ядром kernel способом phase.
+func ApplyFIR(input, kernel []float64, phase FilterPhase) (FilteredSignal, error) {
+	switch phase {
+	case PhaseCausal:
+		return FilteredSignal{Samples: ConvolveAuto(input, kernel), Delay: FIRDelay(kernel)}, nil
+	case PhaseSame:
+		return FilteredSignal{Samples: ConvolveSame(input, kernel), Delay: FIRDelay(kernel) - float64(sameShift(kernel))}, nil
+	case PhaseZero:
+		return FilteredSignal{Samples: FiltFilt(input, kernel)}, nil
+	default:
+		return FilteredSignal{}, fmt.Errorf("unknown filter phase %q, want causal, same or zero-phase", phase)
+	}
+}
+
+// TimeOfFlight рассчитывает время пролёта до первого эха с поправкой на
+// задержку фильтра; индексы эха получены по Samples или по производной
+// от них без сдвига (огибающая Гильберта).
+func (f FilteredSignal) TimeOfFlight(echoIndices []int, sampleRate float64) float64 {
+	return TimeOfFlightWithDelay(echoIndices, sampleRate, f.Delay)
+}
+
+// TimeOfFlightWithDelay рассчитывает время пролёта до первого эха сигнала,
+// задержанного на delay отсчётов:
+//
+//	ToF = (i₀ - delay) / f_s,   i₀ — первый индекс эха не раньше delay
+//
+// Эхо раньше задержки — переходный процесс фильтра — пропускается; если
+// после задержки эха нет, результат -1, как в GetTimeOfFlight.
+func TimeOfFlightWithDelay(echoIndices []int, sampleRate, delay float64) float64 {
+	echoes := EchoesFrom(echoIndices, delay)
+	if len(echoes) == 0 {
+		return -1 // Нет эха
+	}
+	return (float64(echoes[0]) - delay) / sampleRate
+}
+
+// FIRDelay возвращает групповую задержку ядра в отсчётах.
+//
+// Для ядра с линейной фазой (симметричного или антисимметричного) она
+// постоянна на всех частотах:
+//
+//	τ = (k - 1) / 2
+//
+// Для прочих ядер берётся центр энергии импульсной характеристики
+// τ = Σ n·h[n]² / Σ h[n]² — приближение задержки в полосе пропускания.
+func FIRDelay(kernel []float64) float64 {
+	k := len(kernel)
+	if k == 0 {
+		return 0
+	}
+	// Допуск покрывает округление при расчёте отсчётов ядра.
+	if IsLinearPhase(kernel, 1e-9) {
+		return float64(k-1) / 2
+	}
+	var moment, energy float64
+	for n, h := range kernel {
+		moment += float64(n) * h * h
+		energy += h * h
+	}
+	if energy == 0 {
+		return 0
+	}
+	return moment / energy
+}
+
+// IsLinearPhase сообщает, симметрично или антисимметрично ли ядро
+// с точностью tol относительно максимума модуля отсчётов.
+func IsLinearPhase(kernel []float64, tol float64) bool {
+	peak := 0.0
+	for _, h := range kernel {
+		peak = math.Max(peak, math.Abs(h))
+	}
+	limit := tol * peak
+	symmetric, antisymmetric := true, true
+	for i, j := 0, len(kernel)-1; i <= j; i, j = i+1, j-1 {
+		if math.Abs(kernel[i]-kernel[j]) > limit {
+			symmetric = false
+		}
+		if math.Abs(kernel[i]+kernel[j]) > limit {
+			antisymmetric = false
+		}
+	}
+	return symmetric || antisymmetric
+}
+
+// sameShift — целый сдвиг свёртки "same": (k-1)/2 с округлением вниз.
+func sameShift(kernel []float64) int {
+	return max(len(kernel)-1, 0) / 2
+}
+
+// ConvolveSame вычисляет свёртку, выровненную по центру ядра: выход той же
+// длины, что и сигнал, опережает причинную свёртку на s = ⌊(k-1)/2⌋ отсчётов:
+//
+//	y[i] = Σ_{j=0}^{k-1} x[i+s-j]·h[j],   x вне [0, n) считается нулевым
+//
+// Для нечётного ядра с линейной фазой это устраняет задержку полностью,
+// для чётного остаётся половина отсчёта (см. ApplyFIR).
+func ConvolveSame(signal, kernel []float64) []float64 {
+	n, s := len(signal), sameShift(kernel)
+	padded := make([]float64, n+s)
+	copy(padded, signal)
+	return ConvolveAuto(padded, kernel)[s:]
+}
+
+// FiltFilt фильтрует сигнал прямым и обратным проходом: фаза результата
+// нулевая, АЧХ равна |H(f)|².
+//
+//	y₁ = h ∗ x,   y = reverse( h ∗ reverse(y₁) )
+//
+// Чтобы ослабить переходные процессы на краях, сигнал дополняется нечётным
+// отражением длиной до 3(k-1) отсчётов с каждой стороны:
+//
+//	x[-m] = 2·x[0] - x[m],   x[n-1+m] = 2·x[n-1] - x[n-1-m]
+func FiltFilt(signal, kernel []float64) []float64 {
+	n, k := len(signal), len(kernel)
+	if n == 0 || k == 0 {
+		return make([]float64, n)
+	}
+	pad := min(3*(k-1), n-1)
+	ext := make([]float64, n+2*pad)
+	for m := 1; m <= pad; m++ {
+		ext[pad-m] = 2*signal[0] - signal[m]
+		ext[pad+n-1+m] = 2*signal[n-1] - signal[n-1-m]
+	}
+	copy(ext[pad:], signal)
+
+	forward := ConvolveAuto(ext, kernel)
+	reverse(forward)
+	backward := ConvolveAuto(forward, kernel)
+	reverse(backward)
+	return backward[pad : pad+n]
+}
+
+func reverse(x []float64) {
+	for i, j := 0, len(x)-1; i < j; i, j = i+1, j-1 {
+		x[i], x[j] = x[j], x[i]
+	}
+}
+
+// AppliedFIR — ядро вместе со способом применения. Его частотная
+// характеристика описывает то, что ApplyFIR делает с сигналом:
+//
+//	causal:      H(ω)
+//	same:        H(ω)·e^{jωs},  задержка τ(ω) - s
+//	zero-phase:  |H(ω)|²,       задержка 0
+type AppliedFIR struct {
+	Kernel FIR
+	Phase  FilterPhase
+}
+
+// Response возвращает H(e^{jω}) с учётом способа применения.
+func (a AppliedFIR) Response(omega float64) complex128 {
+	h := a.Kernel.Response(omega)
+	switch a.Phase {
+	case PhaseSame:
+		return h * cmplx.Exp(complex(0, omega*float64(sameShift(a.Kernel))))
+	case PhaseZero:
+		return complex(real(h)*real(h)+imag(h)*imag(h), 0)
+	default:
+		return h
+	}
+}
+
+// GroupDelay возвращает групповую задержку в отсчётах с учётом способа применения.
+func (a AppliedFIR) GroupDelay(omega float64) float64 {
+	switch a.Phase {
+	case PhaseSame:
+		return a.Kernel.GroupDelay(omega) - float64(sameShift(a.Kernel))
+	case PhaseZero:
+		return 0
+	default:
+		return a.Kernel.GroupDelay(omega)
+	}
+}
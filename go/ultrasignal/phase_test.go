@@ -0,0 +1,140 @@
+package ultrasignal
+
+import (
+	"math"
+	"testing"
+)
+
+// toneBurst возвращает n отсчётов с пачкой частоты f (Гц) и гауссовой
+// огибающей, центр которой — отсчёт center.
+func toneBurst(n, center int, f, sampleRate float64) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		t := float64(i - center)
+		x[i] = math.Exp(-t*t/(2*20*20)) * math.Cos(2*math.Pi*f*t/sampleRate)
+	}
+	return x
+}
+
+func argmax(x []float64) int {
+	best := 0
+	for i, v := range x {
+		if v > x[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+func TestApplyFIRDelayCompensation(t *testing.T) {
+	const sampleRate, center = 10e6, 600
+	x := toneBurst(2000, center, 1e6, sampleRate)
+	kernel := FIRBandPassKernel(101, 0.5e6, 1.5e6, sampleRate)
+	if d := FIRDelay(kernel); d != 50 {
+		t.Fatalf("FIR delay = %g, want 50", d)
+	}
+
+	tofs := make(map[FilterPhase]float64)
+	for _, phase := range []FilterPhase{PhaseCausal, PhaseSame, PhaseZero} {
+		filtered, err := ApplyFIR(x, kernel, phase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		envelope := ComputeEnvelopeHilbert(filtered.Samples)
+		peak := argmax(envelope)
+		if got := float64(peak) - filtered.Delay; math.Abs(got-center) > 1 {
+			t.Errorf("%s: envelope peak %d with delay %g, want %d", phase, peak, filtered.Delay, center)
+		}
+		echoes := DetectEchoes(envelope, envelope[peak]/2)
+		tofs[phase] = filtered.TimeOfFlight(echoes, sampleRate)
+		if phase == PhaseCausal {
+			// Без поправки время пролёта смещено на задержку фильтра.
+			if bias := GetTimeOfFlight(echoes, sampleRate) - tofs[phase]; math.Abs(bias-50/sampleRate) > 1e-12 {
+				t.Errorf("causal bias %g s, want 50 samples", bias)
+			}
+		}
+	}
+	// Пороговый фронт одинаков при любой фазе; FiltFilt сужает полосу,
+	// поэтому допуск — пара отсчётов.
+	for phase, tof := range tofs {
+		if math.Abs(tof-tofs[PhaseSame]) > 2/sampleRate {
+			t.Errorf("%s: ToF %g, same-mode ToF %g", phase, tof, tofs[PhaseSame])
+		}
+	}
+	if _, err := ApplyFIR(x, kernel, "reverse"); err == nil {
+		t.Error("unknown phase accepted")
+	}
+}
+
+func TestConvolveSame(t *testing.T) {
+	x := make([]float64, 50)
+	x[20] = 1
+	kernel := []float64{1, 2, 3, 2, 1}
+	y := ConvolveSame(x, kernel)
+	if len(y) != len(x) || argmax(y) != 20 || y[18] != 1 || y[22] != 1 {
+		t.Errorf("same convolution of impulse: %v", y[15:26])
+	}
+	// Хвост ядра у конца сигнала не теряется.
+	x[20], x[49] = 0, 1
+	if y := ConvolveSame(x, kernel); y[49] != 3 || y[47] != 1 {
+		t.Errorf("tail: %v", y[45:])
+	}
+}
+
+func TestFiltFiltZeroPhase(t *testing.T) {
+	// Несимметричное ядро: причинная свёртка сдвигает пик, FiltFilt — нет.
+	kernel := []float64{0.5, 0.3, 0.15, 0.05}
+	x := toneBurst(400, 200, 0, 1)
+	if peak := argmax(FiltFilt(x, kernel)); peak != 200 {
+		t.Errorf("filtfilt peak at %d, want 200", peak)
+	}
+	if peak := argmax(Convolve(x, kernel)); peak == 200 {
+		t.Errorf("causal peak at %d, expected a shift", peak)
+	}
+	if d := FIRDelay(kernel); d <= 0 || d >= 1 {
+		t.Errorf("energy centroid delay %g", d)
+	}
+
+	// Нечётное отражение сохраняет постоянный сигнал и на краях.
+	flat := make([]float64, 100)
+	for i := range flat {
+		flat[i] = 3
+	}
+	assertClose(t, "flat", FiltFilt(flat, []float64{0.25, 0.5, 0.25}), flat, 1e-12)
+}
+
+func TestFIRDelay(t *testing.T) {
+	for _, tc := range []struct {
+		kernel []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{1}, 0},
+		{[]float64{1, 1}, 0.5},
+		{[]float64{-1, 0, 1}, 1}, // антисимметричное — тоже линейная фаза
+		{[]float64{0, 0, 1}, 2},
+	} {
+		if got := FIRDelay(tc.kernel); got != tc.want {
+			t.Errorf("FIRDelay(%v) = %g, want %g", tc.kernel, got, tc.want)
+		}
+	}
+	if TimeOfFlightWithDelay(nil, 1, 0) != -1 {
+		t.Error("no echo must give -1")
+	}
+	if got := TimeOfFlightWithDelay([]int{10}, 2, 30); got != -1 {
+		t.Errorf("echo only inside filter transient: %g, want -1", got)
+	}
+	if got := TimeOfFlightWithDelay([]int{10, 29, 40, 41}, 2, 30); got != 5 {
+		t.Errorf("first echo after filter transient: %g, want 5", got)
+	}
+}
+
+func TestEnvelopeHilbertAmplitude(t *testing.T) {
+	// Огибающая пачки с единичной амплитудой не зависит от длины сигнала.
+	for _, n := range []int{1000, 4096} {
+		envelope := ComputeEnvelopeHilbert(toneBurst(n, n/2, 1e6, 10e6))
+		if peak := envelope[argmax(envelope)]; math.Abs(peak-1) > 1e-3 {
+			t.Errorf("n=%d: envelope peak %g, want 1", n, peak)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package ultrasignal
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// Filter — линейный фильтр, заданный передаточной функцией H(z).
+// Частота ω — нормированная, рад/отсчёт: ω = 2π·f / f_s.
+type Filter interface {
+	// Response возвращает H(e^{jω}).
+	Response(omega float64) complex128
+	// GroupDelay возвращает групповую задержку τ(ω) = -dφ/dω в отсчётах.
+	GroupDelay(omega float64) float64
+}
+
+// FIR — ядро КИХ-фильтра как Filter.
+type FIR []float64
+
+// Response вычисляет H(e^{jω}) = Σ h[n]·e^{-jωn}.
+func (h FIR) Response(omega float64) complex128 {
+	return polyResponse(h, omega)
+}
+
+// GroupDelay вычисляет групповую задержку точно, без численного
+// дифференцирования фазы:
+//
+//	τ(ω) = Re( Σ n·h[n]·e^{-jωn} / Σ h[n]·e^{-jωn} )
+//
+// В нулях H задержка не определена (NaN).
+func (h FIR) GroupDelay(omega float64) float64 {
+	return polyDelay(h, omega)
+}
+
+// Response вычисляет H(e^{jω}) каскада как произведение звеньев.
+func (s SOS) Response(omega float64) complex128 {
+	h := complex(1, 0)
+	for _, b := range s {
+		h *= polyResponse([]float64{b.B0, b.B1, b.B2}, omega) / polyResponse([]float64{1, b.A1, b.A2}, omega)
+	}
+	return h
+}
+
+// GroupDelay вычисляет групповую задержку каскада: сумма по звеньям
+// задержек числителя минус задержки знаменателя.
+func (s SOS) GroupDelay(omega float64) float64 {
+	tau := 0.0
+	for _, b := range s {
+		tau += polyDelay([]float64{b.B0, b.B1, b.B2}, omega) - polyDelay([]float64{1, b.A1, b.A2}, omega)
+	}
+	return tau
+}
+
+// polyResponse вычисляет Σ c[n]·e^{-jωn}.
+func polyResponse(c []float64, omega float64) complex128 {
+	var v complex128
+	for n, cn := range c {
+		if cn != 0 {
+			v += complex(cn, 0) * cmplx.Exp(complex(0, -omega*float64(n)))
+		}
+	}
+	return v
+}
+
+// polyDelay вычисляет групповую задержку многочлена Σ c[n]·z⁻ⁿ на окружности.
+func polyDelay(c []float64, omega float64) float64 {
+	var num, den complex128
+	var peak float64
+	for n, cn := range c {
+		if cn == 0 {
+			continue
+		}
+		e := complex(cn, 0) * cmplx.Exp(complex(0, -omega*float64(n)))
+		num += complex(float64(n), 0) * e
+		den += e
+		peak = math.Max(peak, math.Abs(cn))
+	}
+	// Ноль на единичной окружности: фаза скачет на π, задержка не определена.
+	if cmplx.Abs(den) <= 1e-12*peak {
+		return math.NaN()
+	}
+	return real(num / den)
+}
+
+// GridScale — шкала частотной сетки.
+type GridScale string
+
+const (
+	GridLinear GridScale = "linear"
+	GridLog    GridScale = "log"
+)
+
+// FrequencyGrid возвращает points частот от fMin до fMax включительно,
+// Гц, с равномерным шагом по частоте (GridLinear) или по её логарифму
+// (GridLog, как в ComputeFFTLog):
+//
+//	fᵢ = f_min + i·(f_max - f_min)/(points-1)
+//	fᵢ = f_min·(f_max/f_min)^(i/(points-1))
+func FrequencyGrid(scale GridScale, fMin, fMax float64, points int) ([]float64, error) {
+	if points < 2 {
+		return nil, fmt.Errorf("frequency grid needs at least 2 points, got %d", points)
+	}
+	if fMin < 0 || fMax <= fMin {
+		return nil, fmt.Errorf("invalid frequency range %g–%g Hz", fMin, fMax)
+	}
+	freqs := make([]float64, points)
+	last := float64(points - 1)
+	switch scale {
+	case GridLinear:
+		for i := range freqs {
+			freqs[i] = fMin + (fMax-fMin)*float64(i)/last
+		}
+	case GridLog:
+		if fMin == 0 {
+			return nil, fmt.Errorf("log frequency grid needs a positive lower bound")
+		}
+		for i := range freqs {
+			freqs[i] = fMin * math.Pow(fMax/fMin, float64(i)/last)
+		}
+	default:
+		return nil, fmt.Errorf("unknown grid scale %q, want linear or log", scale)
+	}
+	freqs[points-1] = fMax
+	return freqs, nil
+}
+
+// MinMagnitudeDB — нижняя граница АЧХ в дБ: нули H дают её вместо -Inf.
+const MinMagnitudeDB = -300
+
+// FilterResponse — частотная характеристика фильтра на сетке частот.
+type FilterResponse struct {
+	Freqs       []float64    // частоты, Гц
+	H           []complex128 // комплексный коэффициент передачи
+	MagnitudeDB []float64    // 20·log₁₀|H|, не ниже MinMagnitudeDB
+	Phase       []float64    // развёрнутая фаза arg H, рад
+	GroupDelay  []float64    // групповая задержка, отсчёты; NaN в нулях H
+}
+
+// FrequencyResponse вычисляет H(e^{jω}) фильтра на частотах freqs (Гц),
+// ω = 2π·f / f_s, и по ней АЧХ в дБ, развёрнутую фазу и групповую задержку.
+//
+// Фаза разворачивается вдоль сетки: скачки больше π между соседними
+// точками считаются переходом через ±π и компенсируются на 2π. Для
+// корректной развёртки сетка должна быть достаточно плотной, чтобы фаза
+// между соседними частотами менялась меньше чем на π.
+func FrequencyResponse(filter Filter, freqs []float64, sampleRate float64) FilterResponse {
+	r := FilterResponse{
+		Freqs:       append([]float64(nil), freqs...),
+		H:           make([]complex128, len(freqs)),
+		MagnitudeDB: make([]float64, len(freqs)),
+		Phase:       make([]float64, len(freqs)),
+		GroupDelay:  make([]float64, len(freqs)),
+	}
+	for i, f := range freqs {
+		omega := 2 * math.Pi * f / sampleRate
+		h := filter.Response(omega)
+		r.H[i] = h
+		r.MagnitudeDB[i] = math.Max(20*math.Log10(cmplx.Abs(h)), MinMagnitudeDB)
+		r.Phase[i] = cmplx.Phase(h)
+		r.GroupDelay[i] = filter.GroupDelay(omega)
+	}
+	UnwrapPhase(r.Phase)
+	return r
+}
+
+// UnwrapPhase разворачивает фазу на месте: к каждому отсчёту добавляется
+// кратное 2π, при котором разность с предыдущим лежит в [-π, π].
+func UnwrapPhase(phase []float64) {
+	offset := 0.0
+	for i := 1; i < len(phase); i++ {
+		raw := phase[i] + offset
+		d := raw - phase[i-1]
+		if d > math.Pi || d < -math.Pi {
+			k := math.Round(d / (2 * math.Pi))
+			offset -= 2 * math.Pi * k
+			raw -= 2 * math.Pi * k
+		}
+		phase[i] = raw
+	}
+}
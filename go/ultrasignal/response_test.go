@@ -0,0 +1,135 @@
+package ultrasignal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFrequencyGrid(t *testing.T) {
+	lin, err := FrequencyGrid(GridLinear, 0, 100, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "linear", lin, []float64{0, 25, 50, 75, 100}, 1e-12)
+
+	logGrid, err := FrequencyGrid(GridLog, 10, 1e4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertClose(t, "log", logGrid, []float64{10, 100, 1000, 1e4}, 1e-9)
+
+	for _, bad := range []struct {
+		scale      GridScale
+		fMin, fMax float64
+		points     int
+	}{
+		{GridLinear, 0, 100, 1},
+		{GridLinear, 100, 100, 10},
+		{GridLog, 0, 100, 10},
+		{"octave", 1, 100, 10},
+	} {
+		if _, err := FrequencyGrid(bad.scale, bad.fMin, bad.fMax, bad.points); err == nil {
+			t.Errorf("FrequencyGrid(%+v) succeeded", bad)
+		}
+	}
+}
+
+func TestFrequencyResponseFIR(t *testing.T) {
+	const fs = 10e6
+	kernel, err := DesignKaiser(FIRSpec{Type: LowPass, Edges: []float64{1e6, 1.5e6}, PassRippleDB: 0.1, StopDB: 60, SampleRate: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	freqs, _ := FrequencyGrid(GridLinear, 0, 1e6, 101)
+	r := FrequencyResponse(FIR(kernel), freqs, fs)
+
+	// Линейная фаза: φ = -τω с τ = (k-1)/2 во всей полосе пропускания.
+	tau := float64(len(kernel)-1) / 2
+	for i, f := range freqs {
+		omega := 2 * math.Pi * f / fs
+		if math.Abs(r.MagnitudeDB[i]) > 0.1 {
+			t.Errorf("%g Hz: %g dB in pass band", f, r.MagnitudeDB[i])
+		}
+		if math.Abs(r.GroupDelay[i]-tau) > 1e-6 {
+			t.Errorf("%g Hz: group delay %g, want %g", f, r.GroupDelay[i], tau)
+		}
+		if math.Abs(r.Phase[i]+tau*omega) > 1e-6 {
+			t.Errorf("%g Hz: phase %g, want %g", f, r.Phase[i], -tau*omega)
+		}
+	}
+
+	// Ноль на окружности: скользящее среднее из трёх отсчётов при f_s/3.
+	avg := FIR{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	if d := avg.GroupDelay(2 * math.Pi / 3); !math.IsNaN(d) {
+		t.Errorf("group delay at zero = %g, want NaN", d)
+	}
+	zero := FrequencyResponse(avg, []float64{fs / 3}, fs)
+	if zero.MagnitudeDB[0] > -250 || zero.MagnitudeDB[0] < MinMagnitudeDB {
+		t.Errorf("magnitude at zero %g dB", zero.MagnitudeDB[0])
+	}
+}
+
+func TestFrequencyResponseSOS(t *testing.T) {
+	const fs = 10e6
+	sos, err := DesignIIR(IIRSpec{Family: Chebyshev1, Type: BandPass, Order: 3, LowHz: 1e6, HighHz: 2e6, RippleDB: 0.5, SampleRate: fs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	freqs, _ := FrequencyGrid(GridLinear, 0.2e6, 4e6, 2000)
+	r := FrequencyResponse(sos, freqs, fs)
+
+	// Групповая задержка совпадает с -dφ/dω по развёрнутой фазе.
+	for i := 1; i+1 < len(freqs); i++ {
+		dOmega := 2 * math.Pi * (freqs[i+1] - freqs[i-1]) / fs
+		numeric := -(r.Phase[i+1] - r.Phase[i-1]) / dOmega
+		if math.Abs(numeric-r.GroupDelay[i]) > 1e-3*math.Max(1, math.Abs(numeric)) {
+			t.Fatalf("%g Hz: group delay %g, numeric %g", freqs[i], r.GroupDelay[i], numeric)
+		}
+	}
+	edge := FrequencyResponse(sos, []float64{1e6, 2e6}, fs)
+	for i, db := range edge.MagnitudeDB {
+		if math.Abs(db+0.5) > 1e-6 {
+			t.Errorf("band edge %g Hz: %g dB, want -0.5", edge.Freqs[i], db)
+		}
+	}
+}
+
+func TestAppliedFIRResponse(t *testing.T) {
+	const fs = 10e6
+	kernel := FIR(FIRBandPassKernel(101, 0.5e6, 1.5e6, fs))
+	freqs := []float64{0.7e6, 1e6, 1.3e6}
+	causal := FrequencyResponse(AppliedFIR{kernel, PhaseCausal}, freqs, fs)
+	same := FrequencyResponse(AppliedFIR{kernel, PhaseSame}, freqs, fs)
+	zero := FrequencyResponse(AppliedFIR{kernel, PhaseZero}, freqs, fs)
+	for i := range freqs {
+		if math.Abs(causal.GroupDelay[i]-50) > 1e-6 || math.Abs(same.GroupDelay[i]) > 1e-6 || zero.GroupDelay[i] != 0 {
+			t.Errorf("%g Hz: delays causal %g, same %g, zero-phase %g", freqs[i],
+				causal.GroupDelay[i], same.GroupDelay[i], zero.GroupDelay[i])
+		}
+		if math.Abs(zero.MagnitudeDB[i]-2*causal.MagnitudeDB[i]) > 1e-9 || math.Abs(zero.Phase[i]) > 1e-12 {
+			t.Errorf("%g Hz: zero-phase %g dB / %g rad, causal %g dB", freqs[i], zero.MagnitudeDB[i], zero.Phase[i], causal.MagnitudeDB[i])
+		}
+	}
+}
+
+func TestComputeAFCUsesKernel(t *testing.T) {
+	const fs = 1e6
+	kernel := []float64{0.25, 0.5, 0.25} // H(f) = cos²(πf/f_s)
+	freqs, afc := ComputeAFC(kernel, fs)
+	if len(freqs) != 257 || freqs[0] != 0 || freqs[len(freqs)-1] != fs/2 {
+		t.Fatalf("grid %d points %g…%g Hz", len(freqs), freqs[0], freqs[len(freqs)-1])
+	}
+	for i, f := range freqs {
+		c := math.Cos(math.Pi * f / fs)
+		if math.Abs(afc[i]-c*c) > 1e-12 {
+			t.Fatalf("|H(%g)| = %g, want %g", f, afc[i], c*c)
+		}
+	}
+}
+
+func TestUnwrapPhase(t *testing.T) {
+	phase := []float64{3, -3, -2.5, 2.9, 0}
+	UnwrapPhase(phase)
+	want := []float64{3, 2*math.Pi - 3, 2*math.Pi - 2.5, 2.9, 0}
+	assertClose(t, "unwrap", phase, want, 1e-12)
+}
@@ -10,6 +10,7 @@ type UltrasonicSignal struct {
 	FFTMag      []float64
 	Frequencies []float64
 	EchoIndices []int
+	Delay       float64 // задержка Raw относительно принятого сигнала, отсчёты (см. FilteredSignal)
 }
 
 // ComputeEnvelope рассчитывает огибающую выбранным методом
@@ -36,20 +37,15 @@ func (s *UltrasonicSignal) DetectEchoes(threshold float64) {
 	s.EchoIndices = DetectEchoes(s.Envelope, threshold)
 }
 
-// GetTimeOfFlight возвращает время пролета до первого эха
+// GetTimeOfFlight возвращает время пролета до первого эха с поправкой на Delay
 func (s *UltrasonicSignal) GetTimeOfFlight() float64 {
-	if len(s.EchoIndices) == 0 {
-		return -1
-	}
-	return float64(s.EchoIndices[0]) / s.SampleRate
+	return TimeOfFlightWithDelay(s.EchoIndices, s.SampleRate, s.Delay)
 }
 
 // GetTimeOfFlight рассчитывает время пролета (ToF) до первого обнаруженного эхо-сигнала
+// без учёта задержки фильтра; для отфильтрованного сигнала см. FilteredSignal.TimeOfFlight.
 func GetTimeOfFlight(echoIndices []int, sampleRate float64) float64 {
-	if len(echoIndices) == 0 {
-		return -1 // Нет эха
-	}
-	return float64(echoIndices[0]) / sampleRate
+	return TimeOfFlightWithDelay(echoIndices, sampleRate, 0)
 }
 
 // HammingWindow применяет окно Хэмминга к сигналу